@@ -2,16 +2,28 @@ package main
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
 
 	"github.com/opensourceways/robot-framework-lib/client"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
-var regCheckPr = regexp.MustCompile(`(?mi)^/check-pr\s*$`)
-
 func (bot *robot) clearLabel(evt *client.GenericEvent, org, repo, number string) error {
+	noteComment := commentClearLabelCaseByPRUpdate
+	if bot.cli.CheckIfPRReopenEvent(evt) {
+		noteComment = commentClearLabelCaseByReopenPR
+	} else if bot.keepLabelsIfContentIdentical(org, repo, number) {
+		// a rebase onto a newer base without changing the content doesn't need another review
+		return nil
+	}
+
+	return bot.clearReviewLabels(org, repo, number, noteComment)
+}
+
+// clearReviewLabels removes the lgtm and approved labels, and notes them with the comment.
+// The review records before are obsolete too.
+func (bot *robot) clearReviewLabels(org, repo, number, noteComment string) error {
+	bot.saveReview(reviewRecord{Org: org, Repo: repo, Number: number, Command: reviewActionClear})
 
 	labels := bot.getPRLabelSet(org, repo, number)
 	v := getLGTMLabelsOnPR(labels)
@@ -26,15 +38,6 @@ func (bot *robot) clearLabel(evt *client.GenericEvent, org, repo, number string)
 			return nil
 		}
 
-		var noteComment string
-		if bot.cli.CheckIfPRSourceCodeUpdateEvent(evt) {
-			noteComment = commentClearLabelCaseByReopenPR
-		}
-
-		if bot.cli.CheckIfPRSourceCodeUpdateEvent(evt) {
-			noteComment = commentClearLabelCaseByPRUpdate
-		}
-
 		bot.cli.CreatePRComment(
 			org, repo, number,
 			fmt.Sprintf(noteComment, strings.Join(v, ", ")),
@@ -43,6 +46,7 @@ func (bot *robot) clearLabel(evt *client.GenericEvent, org, repo, number string)
 
 	return nil
 }
+
 func (bot *robot) checkCommenterPermission(org, repo, author, commenter string, fn func()) (pass bool) {
 	if author == commenter {
 		return true
@@ -74,53 +78,3 @@ func (bot *robot) getPRLabelSet(org, repo, number string) sets.Set[string] {
 
 	return res
 }
-
-func (bot *robot) genMergeMethod(org, repo, number string) string {
-	mergeMethod := "merge"
-
-	prLabels := bot.getPRLabelSet(org, repo, number)
-
-	for p := range prLabels {
-		if strings.HasPrefix(p, "merge/") {
-			if strings.Split(p, "/")[1] == "squash" {
-				return "squash"
-			}
-
-			return strings.Split(p, "/")[1]
-		}
-	}
-
-	return mergeMethod
-}
-
-func (bot *robot) handleCheckPR(configmap *repoConfig, comment, commenter, org, repo, number string) error {
-	if !regCheckPr.MatchString(comment) {
-		return nil
-	}
-	if err := bot.handleMerge(configmap, org, repo, number); err != nil {
-		claYesLabel := ""
-		for _, labelForMerge := range configmap.LabelsForMerge {
-			if strings.Contains(labelForMerge, "-cla/yes") {
-				claYesLabel = labelForMerge
-				break
-			}
-		}
-		comment := fmt.Sprintf("@%s, this pr is not mergeable and the reasons are below:\n%s\n\n***lgtm***: "+
-			"A label mandatory for merging a pull request. The repository collaborators can comment '/lgtm' to "+
-			"add the label. The creator of a pull request can comment '/lgtm cancel' to remove the label, but "+
-			"cannot run the '/lgtm' command to add the label.\n***approved***: A label mandatory for merging a "+
-			"pull request. The repository collaborators can comment '/approve' to add the label and comment "+
-			"'/approve cancel' to remove the label.\n***%s***:  A label mandatory for merging a pull request. "+
-			"The author of each commit of a pull request must sign the Contributor License Agreement (CLA). "+
-			"Otherwise, the pull request will fail to be merged. After signing the CLA, the author can comment "+
-			"'/check-cla' to check the CLA status again.\n***wait_confirm***: A label for confirming pull request "+
-			"merging. A pull request with this label cannot be automatically merged. This label is added because "+
-			"members (including maintainers, committers, and repository administrators) are to be added to "+
-			"sig-info.yaml in the pull request. To remove the label, all members to be added must comment "+
-			"'/lgtm' in the pull request.",
-			commenter, err.Error(), claYesLabel)
-		bot.cli.CreatePRComment(org, repo, number, comment)
-		return err
-	}
-	return nil
-}
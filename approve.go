@@ -2,58 +2,74 @@ package main
 
 import (
 	"fmt"
-	"regexp"
 
 	"github.com/sirupsen/logrus"
 )
 
 const approvedLabel = "approved"
 
-var (
-	regAddApprove    = regexp.MustCompile(`(?mi)^/approve\s*$`)
-	regRemoveApprove = regexp.MustCompile(`(?mi)^/approve cancel\s*$`)
-)
+func init() {
+	commands.register(&command{
+		name:    "approve",
+		args:    "[cancel]",
+		argsReg: regCancelArg,
+		role:    roleCollaborator,
+		help:    "Add or remove the `approved` label.",
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.handleApprove(c)
+		},
+		denied: func(c *commandContext) string {
+			if c.isCancel() {
+				return fmt.Sprintf(commentNoPermissionForLabel, c.commenter, "remove", approvedLabel)
+			}
+			return fmt.Sprintf(commentNoPermissionForLgtmLabel, c.commenter)
+		},
+	})
+}
 
-func (bot *robot) handleApprove(configmap *repoConfig, comment, commenter, author, org, repo, number string) error {
-	if regAddApprove.MatchString(comment) {
-		return bot.AddApprove(commenter, author, org, repo, number, configmap.LgtmCountsRequired)
+func (bot *robot) handleApprove(c *commandContext) error {
+	if c.isCancel() {
+		if err := bot.removeApprove(c.commenter, c.author, c.org, c.repo, c.number); err != nil {
+			return err
+		}
+		bot.recordReview(c, reviewActionApproveCancel, approvedLabel)
+		return nil
 	}
 
-	if regRemoveApprove.MatchString(comment) {
-		return bot.removeApprove(commenter, author, org, repo, number, configmap.LgtmCountsRequired)
+	if err := bot.AddApprove(c.commenter, c.author, c.org, c.repo, c.number); err != nil {
+		return err
 	}
-
+	c.labelsAdded(approvedLabel)
+	bot.recordReview(c, reviewActionApprove, approvedLabel)
 	return nil
 }
 
-func (bot *robot) AddApprove(commenter, author, org, repo, number string, lgtmCounts uint) error {
+// AddApprove adds the approved label, the commenter must be checked as a collaborator.
+func (bot *robot) AddApprove(commenter, author, org, repo, number string) error {
 	logrus.Infof("AddApprove, commenter: %s, author: %s, org: %s, repo: %s, number: %s", commenter, author, org, repo, number)
-	if pass, ok := bot.cli.CheckPermission(org, repo, commenter); pass && ok {
-		if ok := bot.cli.AddPRLabels(org, repo, number, []string{approvedLabel}); !ok {
-			return fmt.Errorf("failed to add label on pull request")
-		}
-		if ok := bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentAddLabel, approvedLabel, commenter)); !ok {
-			return fmt.Errorf("failed to comment on pull request")
-		}
-	} else if !pass {
-		bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentNoPermissionForLgtmLabel, commenter))
-	} else {
+	if bot.getPRLabelSet(org, repo, number).Has(approvedLabel) {
+		// the command may be replayed, or the pull request has been approved by others
+		return nil
+	}
+	if ok := bot.cli.AddPRLabels(org, repo, number, []string{approvedLabel}); !ok {
 		return fmt.Errorf("failed to add label on pull request")
 	}
+	if ok := bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentAddLabel, approvedLabel, commenter)); !ok {
+		return fmt.Errorf("failed to comment on pull request")
+	}
 	return nil
 }
 
-func (bot *robot) removeApprove(commenter, author, org, repo, number string, lgtmCounts uint) error {
+// removeApprove removes the approved label, the commenter must be checked as a collaborator.
+func (bot *robot) removeApprove(commenter, author, org, repo, number string) error {
 	logrus.Infof("removeApprove, commenter: %s, author: %s, org: %s, repo: %s, number: %s", commenter, author, org, repo, number)
-
-	if pass, ok := bot.cli.CheckPermission(org, repo, commenter); pass && ok {
-		bot.cli.RemovePRLabels(org, repo, number, []string{approvedLabel})
-		bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentRemovedLabel, approvedLabel, commenter))
-	} else if !pass {
-		bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentNoPermissionForLabel, commenter, "remove", approvedLabel))
-	} else {
+	if !bot.getPRLabelSet(org, repo, number).Has(approvedLabel) {
+		return nil
+	}
+	if ok := bot.cli.RemovePRLabels(org, repo, number, []string{approvedLabel}); !ok {
 		return fmt.Errorf("failed to remove label on pull request")
 	}
+	bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentRemovedLabel, approvedLabel, commenter))
 
 	return nil
 }
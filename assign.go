@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	commentAssigned        = `***%s*** %s assigned to this pull request by: ***%s***. :wave: `
+	commentUnassigned      = `***%s*** %s unassigned from this pull request by: ***%s***. :wave: `
+	commentCCed            = `***%s*** %s requested to review this pull request by: ***%s***. :eyes: `
+	commentInvalidReviewer = `***%s*** can not be %s because they are not collaborators of this repository. :astonished: `
+)
+
+var regUsersArg = regexp.MustCompile(`^(@?[\w.-]+)?(\s+@?[\w.-]+)*$`)
+
+func init() {
+	commands.register(&command{
+		name:    "assign",
+		args:    "[@user ...]",
+		argsReg: regUsersArg,
+		role:    roleAuthor,
+		help: "Assign the users to the pull request. Without any user, the reviewers are picked " +
+			"from the reviewer pool of the repository, or the commenter is assigned if there is no pool.",
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.handleAssign(c)
+		},
+	})
+	commands.register(&command{
+		name:    "unassign",
+		args:    "[@user ...]",
+		argsReg: regUsersArg,
+		role:    roleAuthor,
+		help:    "Unassign the users from the pull request. Without any user, the commenter is unassigned.",
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.handleUnassign(c)
+		},
+	})
+	commands.register(&command{
+		name:    "cc",
+		args:    "@user ...",
+		argsReg: regexp.MustCompile(`^@?[\w.-]+(\s+@?[\w.-]+)*$`),
+		role:    roleAuthor,
+		help:    "Request the users to review the pull request.",
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.handleCC(c)
+		},
+	})
+}
+
+func (bot *robot) handleAssign(c *commandContext) error {
+	logrus.Infof("handleAssign, commenter: %s, org: %s, repo: %s, number: %s", c.commenter, c.org, c.repo, c.number)
+	users := parseUsers(c.args)
+	if len(users) == 0 {
+		users = pickReviewers(c.repoCnf, c.author)
+	}
+	if len(users) == 0 {
+		users = []string{c.commenter}
+	}
+
+	users, err := bot.filterCollaborators(c, users, "assigned")
+	if err != nil || len(users) == 0 {
+		return err
+	}
+
+	if ok := bot.cli.AssignPullRequest(c.org, c.repo, c.number, users); !ok {
+		return fmt.Errorf("failed to assign pull request")
+	}
+	if ok := bot.cli.CreatePRComment(c.org, c.repo, c.number,
+		fmt.Sprintf(commentAssigned, joinUsers(users), isOrAre(users), c.commenter)); !ok {
+		return fmt.Errorf("failed to comment on pull request")
+	}
+	return nil
+}
+
+func (bot *robot) handleUnassign(c *commandContext) error {
+	logrus.Infof("handleUnassign, commenter: %s, org: %s, repo: %s, number: %s", c.commenter, c.org, c.repo, c.number)
+	users := parseUsers(c.args)
+	if len(users) == 0 {
+		users = []string{c.commenter}
+	}
+
+	if ok := bot.cli.UnassignPullRequest(c.org, c.repo, c.number, users); !ok {
+		return fmt.Errorf("failed to unassign pull request")
+	}
+	if ok := bot.cli.CreatePRComment(c.org, c.repo, c.number,
+		fmt.Sprintf(commentUnassigned, joinUsers(users), isOrAre(users), c.commenter)); !ok {
+		return fmt.Errorf("failed to comment on pull request")
+	}
+	return nil
+}
+
+func (bot *robot) handleCC(c *commandContext) error {
+	logrus.Infof("handleCC, commenter: %s, org: %s, repo: %s, number: %s", c.commenter, c.org, c.repo, c.number)
+	users, err := bot.filterCollaborators(c, parseUsers(c.args), "requested to review")
+	if err != nil || len(users) == 0 {
+		return err
+	}
+
+	if ok := bot.cli.RequestPullRequestReviewers(c.org, c.repo, c.number, users); !ok {
+		return fmt.Errorf("failed to request reviewers of pull request")
+	}
+	if ok := bot.cli.CreatePRComment(c.org, c.repo, c.number,
+		fmt.Sprintf(commentCCed, joinUsers(users), isOrAre(users), c.commenter)); !ok {
+		return fmt.Errorf("failed to comment on pull request")
+	}
+	return nil
+}
+
+// filterCollaborators returns the users who are collaborators of the repository,
+// and tells the commenter who are not.
+func (bot *robot) filterCollaborators(c *commandContext, users []string, action string) ([]string, error) {
+	var valid, invalid []string
+	for _, u := range users {
+		pass, ok := bot.cli.CheckPermission(c.org, c.repo, u)
+		if !ok {
+			return nil, fmt.Errorf("failed to check the permission of %s", u)
+		}
+		if pass {
+			valid = append(valid, u)
+		} else {
+			invalid = append(invalid, u)
+		}
+	}
+
+	if len(invalid) > 0 {
+		bot.cli.CreatePRComment(c.org, c.repo, c.number,
+			fmt.Sprintf(commentInvalidReviewer, joinUsers(invalid), action))
+	}
+
+	return valid, nil
+}
+
+// pickReviewers picks the users from the reviewer pool randomly, the author is excluded.
+func pickReviewers(repoCnf *repoConfig, author string) []string {
+	pool := sets.New[string](repoCnf.ReviewerPool...)
+	pool.Delete(author, "")
+	candidates := sets.List(pool)
+
+	n := repoCnf.ReviewerPoolAssignCount
+	if n <= 0 {
+		n = 1
+	}
+	if n > len(candidates) {
+		n = len(candidates)
+	}
+
+	picked := make([]string, 0, n)
+	for _, i := range rand.Perm(len(candidates))[:n] {
+		picked = append(picked, candidates[i])
+	}
+
+	return picked
+}
+
+func parseUsers(args []string) []string {
+	users := sets.New[string]()
+	for _, v := range args {
+		if u := strings.TrimPrefix(v, "@"); u != "" {
+			users.Insert(u)
+		}
+	}
+
+	return sets.List(users)
+}
+
+func joinUsers(users []string) string {
+	v := make([]string, len(users))
+	for i := range users {
+		v[i] = "@" + users[i]
+	}
+
+	return strings.Join(v, ", ")
+}
+
+func isOrAre(users []string) string {
+	if len(users) > 1 {
+		return "are"
+	}
+
+	return "is"
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// reactionAck is the reaction to the comment whose commands are handled
+	reactionAck = "+1"
+
+	reasonLabelTooLong      = "***%s*** is longer than %d characters which is the limit of this platform."
+	reasonLabelInvalidChars = "***%s*** contains characters which can't be used in a label on this platform."
+)
+
+// capabilities is what a platform supports. The robot follows it instead of assuming the limits of one platform.
+type capabilities struct {
+	// labelLenLimit is the maximum length of a label, 0 means no limit.
+	labelLenLimit int
+	// invalidLabelChars matches the characters which can't be used in a label, nil means any is allowed.
+	invalidLabelChars *regexp.Regexp
+	// mergeMethods is the methods which the platform can merge the pull request by.
+	mergeMethods []string
+	// reactions means the comments can be reacted to, which is used to acknowledge the commands.
+	reactions bool
+	// draft means the pull request can be a draft.
+	draft bool
+}
+
+// labelProblem returns why the label can't be used on the platform, an empty string means it can.
+func (caps *capabilities) labelProblem(label string) string {
+	if caps.labelLenLimit > 0 && len(label) > caps.labelLenLimit {
+		return fmt.Sprintf(reasonLabelTooLong, label, caps.labelLenLimit)
+	}
+	if caps.invalidLabelChars != nil && caps.invalidLabelChars.MatchString(label) {
+		return fmt.Sprintf(reasonLabelInvalidChars, label)
+	}
+
+	return ""
+}
+
+// supportsMergeMethod checks whether the platform can merge the pull request by the method.
+func (caps *capabilities) supportsMergeMethod(method string) bool {
+	for _, m := range caps.mergeMethods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowedMergeMethods returns the methods allowed by the repository which the platform supports.
+func (caps *capabilities) allowedMergeMethods(repoCnf *repoConfig) []string {
+	var r []string
+	for _, m := range repoCnf.allowedMergeMethods() {
+		if caps.supportsMergeMethod(m) {
+			r = append(r, m)
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	markPassed = ":white_check_mark:"
+	markFailed = ":x:"
+
+	commentCheckPRReport = `@%s, this pr is not mergeable and the reasons are below:
+
+| | condition | who can fix it | description |
+| --- | --- | --- | --- |
+%s`
+
+	fixerLgtm     = "Collaborators of this repository by commenting `/lgtm`."
+	fixerApproved = "Collaborators of this repository by commenting `/approve`."
+	fixerLegality = "Remove the label and add it again by the corresponding command."
+	fixerPlatform = "The author of the pull request."
+
+	descLgtm = "A label mandatory for merging a pull request. The creator of a pull request can comment " +
+		"`/lgtm cancel` to remove the label, but cannot run the `/lgtm` command to add the label."
+	descApproved  = "A label mandatory for merging a pull request. It can be removed by `/approve cancel`."
+	descLegality  = "The labels for merging must be added by ***%s***."
+	notConfigured = "-"
+)
+
+// readinessItem is one condition of merging a pull request in the check-pr report.
+type readinessItem struct {
+	passed      bool
+	condition   string
+	fixer       string
+	description string
+}
+
+func (i *readinessItem) String() string {
+	mark := markFailed
+	if i.passed {
+		mark = markPassed
+	}
+
+	return fmt.Sprintf("| %s | %s | %s | %s |", mark, i.condition, i.fixer, i.description)
+}
+
+func init() {
+	commands.register(&command{
+		name: "check-pr",
+		role: roleAnyone,
+		help: "Check whether the pull request meets the merge conditions, and merge it if so. " +
+			"Otherwise, a report of each condition is shown.",
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.handleCheckPR(c.repoCnf, c.commenter, c.org, c.repo, c.number)
+		},
+	})
+}
+
+func (bot *robot) handleCheckPR(configmap *repoConfig, commenter, org, repo, number string) error {
+	err := bot.handleMerge(configmap, org, repo, number)
+	if err == nil || errors.Is(err, errPRNotOpen) {
+		return nil
+	}
+
+	labels := bot.getPRLabelSet(org, repo, number)
+	ops, ok := bot.cli.ListPullRequestOperationLogs(org, repo, number)
+	items := checkReadiness(configmap, bot.legalOperator(configmap, org), labels, ops, ok)
+
+	if allPassed(items) {
+		// the labels are ready, so it is the platform that refuses to merge
+		items = append(items, readinessItem{
+			condition:   "PR can be merged by the platform",
+			fixer:       fixerPlatform,
+			description: err.Error(),
+		})
+	}
+
+	rows := make([]string, len(items))
+	for i := range items {
+		rows[i] = items[i].String()
+	}
+	bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentCheckPRReport, commenter, strings.Join(rows, "\n")))
+
+	return err
+}
+
+// checkReadiness checks each condition of merging the pull request, it is the same as what handleMerge does.
+func checkReadiness(configmap *repoConfig, legalOperator string,
+	labels sets.Set[string], ops []client.PullRequestOperationLog, opsListed bool,
+) []readinessItem {
+	var items []readinessItem
+
+	switch ln := configmap.LgtmCountsRequired; {
+	case ln == 1:
+		item := configmap.requiredLabelItem(lgtmLabel, fixerLgtm, descLgtm, labels)
+		if item.passed {
+			item.description += configmap.labelAges(ops, lgtmLabel)
+		}
+		items = append(items, item)
+	case ln > 1:
+		v := sets.List(sets.New[string](getLGTMLabelsOnPR(labels)...))
+		n := uint(len(v))
+		items = append(items, readinessItem{
+			passed:      n >= ln,
+			condition:   fmt.Sprintf("%d ***%s-*** labels are required, got %d", ln, lgtmLabel, n),
+			fixer:       fixerLgtm,
+			description: configmap.labelDescription(lgtmLabel, descLgtm) + configmap.labelAges(ops, v...),
+		})
+	}
+
+	item := configmap.requiredLabelItem(approvedLabel, fixerApproved, descApproved, labels)
+	if item.passed {
+		item.description += configmap.labelAges(ops, approvedLabel)
+	}
+	items = append(items, item)
+
+	for _, l := range configmap.LabelsForMerge {
+		items = append(items, configmap.requiredLabelItem(l, configmap.labelFixer(l, notConfigured),
+			configmap.labelDescription(l, notConfigured), labels))
+	}
+
+	for i := range blockingLabels {
+		b := &blockingLabels[i]
+		items = append(items, readinessItem{
+			passed:      !labels.Has(b.label),
+			condition:   fmt.Sprintf("***%s*** must not exist", b.label),
+			fixer:       b.fixer,
+			description: configmap.labelDescription(b.label, b.desc),
+		})
+	}
+	for _, l := range configmap.LabelsNotAllowMerge {
+		if isBlockingLabel(l) {
+			continue
+		}
+		items = append(items, readinessItem{
+			passed:      !labels.Has(l),
+			condition:   fmt.Sprintf("***%s*** must not exist", l),
+			fixer:       configmap.labelFixer(l, notConfigured),
+			description: configmap.labelDescription(l, notConfigured),
+		})
+	}
+
+	legality := readinessItem{
+		passed:      true,
+		condition:   "labels for merging are added legally",
+		fixer:       fixerLegality,
+		description: fmt.Sprintf(descLegality, legalOperator),
+	}
+	if !opsListed {
+		legality.passed = false
+		legality.description = "Failed to list the operation logs of the pull request, please try again."
+	} else if err := checkLabelsLegal(configmap, legalOperator, ops, labels); err != nil {
+		legality.passed = false
+		legality.description = strings.ReplaceAll(err.Error(), "\n\n", "<br/>")
+	}
+	items = append(items, legality)
+
+	return items
+}
+
+// labelAges tells how long it is since each label was added, and when it expires.
+func (c *repoConfig) labelAges(ops []client.PullRequestOperationLog, labels ...string) string {
+	var s strings.Builder
+	for _, l := range labels {
+		age, ok := labelAge(ops, l)
+		if !ok {
+			continue
+		}
+
+		s.WriteString(fmt.Sprintf("<br/>***%s*** was added %s ago", l, formatAge(age)))
+		if c.ReviewMaxAgeDays > 0 {
+			s.WriteString(fmt.Sprintf(", it expires after %d days", c.ReviewMaxAgeDays))
+		}
+		s.WriteString(".")
+	}
+
+	return s.String()
+}
+
+func allPassed(items []readinessItem) bool {
+	for i := range items {
+		if !items[i].passed {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *repoConfig) requiredLabelItem(label, fixer, desc string, labels sets.Set[string]) readinessItem {
+	return readinessItem{
+		passed:      labels.Has(label),
+		condition:   fmt.Sprintf("***%s*** is required", label),
+		fixer:       c.labelFixer(label, fixer),
+		description: c.labelDescription(label, desc),
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opensourceways/go-gitcode/openapi"
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/opensourceways/robot-framework-lib/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	gitcodeAPIBaseURL = "https://api.gitcode.com/api/v5/"
+	perPage           = 100
+)
+
+// pullRequestInfo is the information of a pull request which the events don't carry.
+type pullRequestInfo struct {
+	Number   string
+	Title    string
+	Author   string
+	Draft    bool
+	HeadSHA  string
+	MergedBy string
+	// Closed means the pull request is closed or merged
+	Closed bool
+	Labels []string
+	// UpdatedAt is the last time when the pull request is changed
+	UpdatedAt time.Time
+}
+
+// isClosedState checks the state of a pull request returned by the platforms, which is one of
+// open, opened, closed, merged and locked.
+func isClosedState(state string) bool {
+	return state == "closed" || state == "merged"
+}
+
+func toPullRequestInfo(pr *openapi.PullRequest) pullRequestInfo {
+	info := pullRequestInfo{
+		Title:  utils.GetString(pr.Title),
+		Draft:  pr.Draft != nil && *pr.Draft,
+		Closed: isClosedState(utils.GetString(pr.State)),
+	}
+	if pr.Number != nil {
+		info.Number = strconv.FormatInt(*pr.Number, 10)
+	}
+	if pr.User != nil {
+		info.Author = utils.GetString(pr.User.Login)
+	}
+	if pr.Head != nil {
+		info.HeadSHA = utils.GetString(pr.Head.SHA)
+	}
+	if pr.MergedBy != nil {
+		info.MergedBy = utils.GetString(pr.MergedBy.Login)
+	}
+	if pr.UpdatedAt != nil {
+		info.UpdatedAt = time.Time(*pr.UpdatedAt)
+	}
+	for _, l := range pr.Labels {
+		if l != nil && l.Name != "" {
+			info.Labels = append(info.Labels, l.Name)
+		}
+	}
+
+	return info
+}
+
+// gitcodeClient extends the client of robot-framework-lib with the APIs it doesn't provide.
+type gitcodeClient struct {
+	client.Client
+	api    *openapi.APIClient
+	logger *logrus.Entry
+}
+
+func newGitCodeClient(token []byte, logger *logrus.Entry) *gitcodeClient {
+	return &gitcodeClient{
+		Client: client.NewClient(token, logger),
+		api:    openapi.NewAPIClientWithAuthorization(token),
+		logger: logger,
+	}
+}
+
+// do sends a request to the GitCode OpenAPI, the body is encoded as json and
+// the response is decoded into the receiver if it is not nil.
+func (c *gitcodeClient) do(method, path string, query url.Values, body, receiver any) (success bool) {
+	err := c.send(method, path, query, body, receiver)
+	if err != nil {
+		c.logger.WithError(err).Errorf("failed to request %s %s", method, path)
+	}
+
+	return err == nil
+}
+
+func (c *gitcodeClient) send(method, path string, query url.Values, body, receiver any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	urlStr := gitcodeAPIBaseURL + path
+	if len(query) > 0 {
+		urlStr += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(method, urlStr, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.api.Do(context.Background(), req, receiver)
+	if err != nil {
+		return err
+	}
+	if resp == nil || resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected response of %s", urlStr)
+	}
+
+	return nil
+}
+
+func (c *gitcodeClient) logging(err error) {
+	pc, _, line, _ := runtime.Caller(1)
+	c.logger.WithError(err).Errorf("the call func name[%s] and line[%d]", runtime.FuncForPC(pc).Name(), line)
+}
+
+// RemovePRLabels removes the labels from the pull request. The labels are a part of the request path,
+// so they are escaped in case they contain a slash, such as 'merge/squash'.
+func (c *gitcodeClient) RemovePRLabels(org, repo, number string, labels []string) (success bool) {
+	escaped := make([]string, len(labels))
+	for i := range labels {
+		escaped[i] = url.QueryEscape(labels[i])
+	}
+
+	return c.Client.RemovePRLabels(org, repo, number, escaped)
+}
+
+func (c *gitcodeClient) AssignPullRequest(org, repo, number string, logins []string) (success bool) {
+	if len(logins) == 0 {
+		return
+	}
+
+	return c.do(http.MethodPost, fmt.Sprintf("repos/%s/%s/pulls/%s/assignees", org, repo, number), nil,
+		map[string]string{"assignees": strings.Join(logins, ",")}, nil)
+}
+
+func (c *gitcodeClient) UnassignPullRequest(org, repo, number string, logins []string) (success bool) {
+	if len(logins) == 0 {
+		return
+	}
+
+	return c.do(http.MethodDelete, fmt.Sprintf("repos/%s/%s/pulls/%s/assignees", org, repo, number),
+		url.Values{"assignees": []string{strings.Join(logins, ",")}}, nil, nil)
+}
+
+func (c *gitcodeClient) RequestPullRequestReviewers(org, repo, number string, logins []string) (success bool) {
+	if len(logins) == 0 {
+		return
+	}
+
+	return c.do(http.MethodPost, fmt.Sprintf("repos/%s/%s/pulls/%s/requested_reviewers", org, repo, number), nil,
+		map[string][]string{"reviewers": logins}, nil)
+}
+
+// AddCommentReaction is not supported by GitCode.
+func (c *gitcodeClient) AddCommentReaction(org, repo, number, commentID, reaction string) (success bool) {
+	return false
+}
+
+func (c *gitcodeClient) GetCurrentUser() (login string, success bool) {
+	var v struct {
+		Login string `json:"login"`
+	}
+	if !c.do(http.MethodGet, "user", nil, nil, &v) {
+		return "", false
+	}
+
+	return v.Login, true
+}
+
+func (c *gitcodeClient) CheckIfPRClosedEvent(evt *client.GenericEvent) (yes bool) {
+	return genericEvents{}.CheckIfPRClosedEvent(evt)
+}
+
+func (c *gitcodeClient) CheckIfPRMergedEvent(evt *client.GenericEvent) (yes bool) {
+	return genericEvents{}.CheckIfPRMergedEvent(evt)
+}
+
+func (c *gitcodeClient) ClosePullRequest(org, repo, number string) (success bool) {
+	return c.UpdatePR(org, repo, number, "closed")
+}
+
+func (c *gitcodeClient) ReopenPullRequest(org, repo, number string) (success bool) {
+	return c.UpdatePR(org, repo, number, "open")
+}
+
+func (c *gitcodeClient) GetPullRequestInfo(org, repo, number string) (result pullRequestInfo, success bool) {
+	pr, success, err := c.api.PullRequests.GetPullRequest(context.Background(), org, repo, number)
+	if err != nil {
+		c.logging(err)
+		return result, false
+	}
+	if !success || pr == nil {
+		return result, false
+	}
+
+	return toPullRequestInfo(pr), true
+}
+
+func (c *gitcodeClient) ListOpenPullRequests(org, repo string) (result []pullRequestInfo, success bool) {
+	path := fmt.Sprintf("repos/%s/%s/pulls", org, repo)
+	for page := 1; ; page++ {
+		var prs []*openapi.PullRequest
+		query := url.Values{
+			"state":    []string{"open"},
+			"page":     []string{strconv.Itoa(page)},
+			"per_page": []string{strconv.Itoa(perPage)},
+		}
+		if !c.do(http.MethodGet, path, query, nil, &prs) {
+			return nil, false
+		}
+
+		for _, pr := range prs {
+			if pr != nil {
+				result = append(result, toPullRequestInfo(pr))
+			}
+		}
+		if len(prs) < perPage {
+			return result, true
+		}
+	}
+}
+
+func (c *gitcodeClient) UpdatePullRequestTitle(org, repo, number, title string) (success bool) {
+	_, success, err := c.api.PullRequests.UpdatePullRequest(context.Background(), org, repo, number,
+		&openapi.PullRequestRequest{Title: title})
+	if err != nil {
+		c.logging(err)
+		return false
+	}
+	return
+}
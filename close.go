@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	commentClosedPR   = `This pull request was closed by: ***%s***. :wave: `
+	commentReopenedPR = `This pull request was reopened by: ***%s***. :wave: `
+)
+
+func init() {
+	commands.register(&command{
+		name: "close",
+		role: roleAuthor,
+		help: "Close the pull request.",
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.closePR(c.commenter, c.org, c.repo, c.number)
+		},
+	})
+	commands.register(&command{
+		name: "reopen",
+		role: roleAuthor,
+		help: "Reopen the closed pull request, the `lgtm` and `approved` labels will be removed.",
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.reopenPR(c.commenter, c.org, c.repo, c.number)
+		},
+	})
+}
+
+func (bot *robot) closePR(commenter, org, repo, number string) error {
+	logrus.Infof("closePR, commenter: %s, org: %s, repo: %s, number: %s", commenter, org, repo, number)
+	if ok := bot.cli.ClosePullRequest(org, repo, number); !ok {
+		return fmt.Errorf("failed to close pull request")
+	}
+	if ok := bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentClosedPR, commenter)); !ok {
+		return fmt.Errorf("failed to comment on pull request")
+	}
+	return nil
+}
+
+func (bot *robot) reopenPR(commenter, org, repo, number string) error {
+	logrus.Infof("reopenPR, commenter: %s, org: %s, repo: %s, number: %s", commenter, org, repo, number)
+	if ok := bot.cli.ReopenPullRequest(org, repo, number); !ok {
+		return fmt.Errorf("failed to reopen pull request")
+	}
+	if ok := bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentReopenedPR, commenter)); !ok {
+		return fmt.Errorf("failed to comment on pull request")
+	}
+
+	// the labels are cleared by the reopen event, the same as reopening it in the web page
+	return nil
+}
@@ -0,0 +1,266 @@
+// Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// commandRole is the minimum role a commenter must have to run a command.
+type commandRole int
+
+const (
+	// roleAnyone means everyone can run the command.
+	roleAnyone commandRole = iota
+	// roleAuthor means the author of the pull request or the collaborators of the repository.
+	roleAuthor
+	// roleCollaborator means the collaborators of the repository only.
+	roleCollaborator
+)
+
+func (r commandRole) String() string {
+	switch r {
+	case roleAuthor:
+		return "Pull request author and collaborators of this repository."
+	case roleCollaborator:
+		return "Collaborators of this repository."
+	default:
+		return "Anyone."
+	}
+}
+
+const (
+	commentNoPermissionForCommand = `***@%s*** has no permission to use the command ***/%s*** in this pull request. :astonished:
+Please contact to the collaborators in this repository.`
+	commentCommandHelp = `@%s, the following commands are available in this repository:
+
+| command | aliases | description | who can use |
+| ------- | ------- | ----------- | ----------- |
+%s`
+)
+
+// regCommand matches a line which starts with a slash command, the first group is the name
+// and the second one is the arguments.
+var regCommand = regexp.MustCompile(`^/([\w-]+)(?:[ \t]+(.*?))?\s*$`)
+
+// commandContext holds everything a command handler needs to process one command line.
+type commandContext struct {
+	repoCnf   *repoConfig
+	org       string
+	repo      string
+	number    string
+	commenter string
+	author    string
+	// name is the name or alias used by the commenter.
+	name string
+	// args is the arguments following the command name split by white space.
+	args []string
+	// commentID is the id of the comment which contains the command.
+	commentID string
+	// addedLabels is the labels added by the command.
+	addedLabels []string
+	// handled means at least one command in the comment has been handled.
+	handled bool
+}
+
+// labelsAdded tells the dispatcher the labels added by the command,
+// so that they can be revoked when the comment is edited or deleted.
+func (c *commandContext) labelsAdded(labels ...string) {
+	c.addedLabels = append(c.addedLabels, labels...)
+}
+
+// isCancel reports whether the command was called with a single "cancel" argument.
+func (c *commandContext) isCancel() bool {
+	return len(c.args) == 1 && strings.EqualFold(c.args[0], "cancel")
+}
+
+// command describes a slash command which can be used in pull request comments.
+type command struct {
+	// name is the primary name of the command without the leading slash.
+	name string
+	// aliases are the other names which can be used to call the command.
+	aliases []string
+	// args is the argument syntax displayed in the help message.
+	args string
+	// argsReg validates the arguments, nil means the command accepts no arguments.
+	argsReg *regexp.Regexp
+	// role is the minimum role required to run the command.
+	role commandRole
+	// help is the description displayed in the help message.
+	help string
+	// handler processes the command.
+	handler func(bot *robot, c *commandContext) error
+	// denied returns the comment to post when the commenter has no permission,
+	// nil means using the default one.
+	denied func(c *commandContext) string
+}
+
+func (cmd *command) acceptArgs(args string) bool {
+	if cmd.argsReg == nil {
+		return args == ""
+	}
+
+	return cmd.argsReg.MatchString(args)
+}
+
+func (cmd *command) deniedComment(c *commandContext) string {
+	if cmd.denied != nil {
+		return cmd.denied(c)
+	}
+
+	return fmt.Sprintf(commentNoPermissionForCommand, c.commenter, cmd.name)
+}
+
+// commandRegistry keeps the commands by their names and aliases.
+type commandRegistry struct {
+	commands []*command
+	index    map[string]*command
+}
+
+var commands = &commandRegistry{index: map[string]*command{}}
+
+// register adds a command to the registry. It panics if the name or any alias is duplicated.
+func (r *commandRegistry) register(cmd *command) {
+	for _, n := range append([]string{cmd.name}, cmd.aliases...) {
+		k := strings.ToLower(n)
+		if _, ok := r.index[k]; ok {
+			panic("duplicate command: " + n)
+		}
+		r.index[k] = cmd
+	}
+
+	r.commands = append(r.commands, cmd)
+}
+
+// lookup returns the command called by the name or alias.
+func (r *commandRegistry) lookup(name string) *command {
+	return r.index[strings.ToLower(name)]
+}
+
+// enabled returns the commands enabled for the repo sorted by name.
+func (r *commandRegistry) enabled(repoCnf *repoConfig) []*command {
+	v := make([]*command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		if repoCnf.isCommandEnabled(cmd, cmd.name) {
+			v = append(v, cmd)
+		}
+	}
+
+	sort.Slice(v, func(i, j int) bool {
+		return v[i].name < v[j].name
+	})
+
+	return v
+}
+
+// parseCommand splits a comment line into the command name and arguments.
+func parseCommand(line string) (name, args string, ok bool) {
+	m := regCommand.FindStringSubmatch(strings.TrimRight(line, "\r"))
+	if m == nil {
+		return "", "", false
+	}
+
+	return m[1], m[2], true
+}
+
+// handleCommand runs the registered command called in the line if there is one.
+func (bot *robot) handleCommand(c *commandContext, line string) error {
+	name, args, ok := parseCommand(line)
+	if !ok {
+		return nil
+	}
+
+	cmd := commands.lookup(name)
+	if cmd == nil || !c.repoCnf.isCommandEnabled(cmd, name) || !cmd.acceptArgs(args) {
+		return nil
+	}
+
+	c.name = name
+	c.args = strings.Fields(args)
+	c.addedLabels = nil
+
+	pass, err := bot.hasRole(cmd.role, c)
+	if err != nil {
+		return err
+	}
+	if !pass {
+		bot.cli.CreatePRComment(c.org, c.repo, c.number, cmd.deniedComment(c))
+		return nil
+	}
+
+	if err := cmd.handler(bot, c); err != nil {
+		return err
+	}
+
+	c.handled = true
+	bot.recordLabels(c, cmd)
+	return nil
+}
+
+// hasRole checks whether the commenter has the role. It returns an error when the permission can't be checked.
+func (bot *robot) hasRole(role commandRole, c *commandContext) (bool, error) {
+	if role == roleAnyone || (role == roleAuthor && c.author == c.commenter) {
+		return true, nil
+	}
+
+	pass, success := bot.cli.CheckPermission(c.org, c.repo, c.commenter)
+	bot.log.Infof("request success: %t, the %s has permission to the repo[%s/%s]: %t",
+		success, c.commenter, c.org, c.repo, pass)
+	if !success {
+		return false, fmt.Errorf("failed to check the permission of %s", c.commenter)
+	}
+
+	return pass, nil
+}
+
+func init() {
+	commands.register(&command{
+		name: "help",
+		role: roleAnyone,
+		help: "Show the commands available in this repository.",
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.handleHelp(c)
+		},
+	})
+}
+
+func (bot *robot) handleHelp(c *commandContext) error {
+	var rows []string
+	for _, cmd := range commands.enabled(c.repoCnf) {
+		usage := "/" + cmd.name
+		if cmd.args != "" {
+			usage += " " + cmd.args
+		}
+
+		aliases := make([]string, 0, len(cmd.aliases))
+		for _, v := range cmd.aliases {
+			if c.repoCnf.isCommandEnabled(cmd, v) {
+				aliases = append(aliases, "/"+v)
+			}
+		}
+
+		rows = append(rows, fmt.Sprintf("| %s | %s | %s | %s |",
+			strings.ReplaceAll(usage, "|", "\\|"), strings.Join(aliases, ", "), cmd.help, cmd.role))
+	}
+
+	if ok := bot.cli.CreatePRComment(c.org, c.repo, c.number,
+		fmt.Sprintf(commentCommandHelp, c.commenter, strings.Join(rows, "\n"))); !ok {
+		return fmt.Errorf("failed to comment on pull request")
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const commentRevokedLabel = `***%s*** was removed in this pull request because the %s which added it was %s by: ***%s***. :flushed: `
+
+// commandSource records the labels added by a command in a comment.
+type commandSource struct {
+	commentID string
+	// user is who posted the comment
+	user string
+	// command is the primary name of the command
+	command string
+	labels  []string
+}
+
+// commandSources remembers which comment produced which label for each pull request.
+type commandSources struct {
+	lock    sync.Mutex
+	sources map[string][]commandSource
+}
+
+func newCommandSources() *commandSources {
+	return &commandSources{sources: map[string][]commandSource{}}
+}
+
+func prKey(org, repo, number string) string {
+	return org + "/" + repo + "/" + number
+}
+
+func (s *commandSources) add(key string, src commandSource) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.sources[key] = append(s.sources[key], src)
+}
+
+// take removes and returns the sources of the comment which meet the condition.
+func (s *commandSources) take(key, commentID string, match func(*commandSource) bool) []commandSource {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var taken, kept []commandSource
+	for _, v := range s.sources[key] {
+		if v.commentID == commentID && match(&v) {
+			taken = append(taken, v)
+		} else {
+			kept = append(kept, v)
+		}
+	}
+
+	if len(kept) == 0 {
+		delete(s.sources, key)
+	} else {
+		s.sources[key] = kept
+	}
+
+	return taken
+}
+
+// forget drops all the sources of the pull request.
+func (s *commandSources) forget(key string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.sources, key)
+}
+
+// held returns the labels still recorded for the pull request.
+func (s *commandSources) held(key string) sets.Set[string] {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	r := sets.New[string]()
+	for i := range s.sources[key] {
+		r.Insert(s.sources[key][i].labels...)
+	}
+
+	return r
+}
+
+// recordLabels remembers the labels added by the command, so they can be revoked with the comment.
+func (bot *robot) recordLabels(c *commandContext, cmd *command) {
+	if c.commentID == "" || len(c.addedLabels) == 0 {
+		return
+	}
+
+	bot.sources.add(prKey(c.org, c.repo, c.number), commandSource{
+		commentID: c.commentID,
+		user:      c.commenter,
+		command:   cmd.name,
+		labels:    c.addedLabels,
+	})
+}
+
+// handleCommentEdited revokes the labels whose commands are no longer in the comment.
+func (bot *robot) handleCommentEdited(c *commandContext, comment string) error {
+	present := sets.New[string]()
+	for _, line := range strings.Split(comment, "\n") {
+		name, args, ok := parseCommand(line)
+		if !ok {
+			continue
+		}
+		if cmd := commands.lookup(name); cmd != nil && cmd.acceptArgs(args) {
+			if v := strings.Fields(args); !(len(v) == 1 && strings.EqualFold(v[0], "cancel")) {
+				present.Insert(cmd.name)
+			}
+		}
+	}
+
+	match := func(src *commandSource) bool {
+		return !present.Has(src.command)
+	}
+	taken := bot.sources.take(prKey(c.org, c.repo, c.number), c.commentID, match)
+	if len(taken) == 0 {
+		for _, src := range bot.storedSources(c.org, c.repo, c.number, c.commentID) {
+			if match(&src) {
+				taken = append(taken, src)
+			}
+		}
+	}
+
+	return bot.revokeLabels(c, taken, "comment", "edited")
+}
+
+// handleCommentDeleted revokes all the labels added by the comment.
+func (bot *robot) handleCommentDeleted(c *commandContext) error {
+	taken := bot.sources.take(prKey(c.org, c.repo, c.number), c.commentID, func(*commandSource) bool {
+		return true
+	})
+	if len(taken) == 0 {
+		taken = bot.storedSources(c.org, c.repo, c.number, c.commentID)
+	}
+
+	return bot.revokeLabels(c, taken, "comment", "deleted")
+}
+
+// revokeLabels removes the labels added by the sources, which is the comment or the review told by source.
+func (bot *robot) revokeLabels(c *commandContext, sources []commandSource, source, how string) error {
+	want := sets.New[string]()
+	for i := range sources {
+		want.Insert(sources[i].labels...)
+	}
+	// the labels added by other comments are kept
+	want = want.Difference(bot.sources.held(prKey(c.org, c.repo, c.number)))
+
+	v := sets.List(want.Intersection(bot.getPRLabelSet(c.org, c.repo, c.number)))
+	if len(v) == 0 {
+		return nil
+	}
+
+	logrus.Infof("revokeLabels, labels: %v, commenter: %s, org: %s, repo: %s, number: %s",
+		v, c.commenter, c.org, c.repo, c.number)
+	if ok := bot.cli.RemovePRLabels(c.org, c.repo, c.number, v); !ok {
+		return fmt.Errorf("failed to remove label on pull request")
+	}
+	bot.recordRevokedReviews(c, sources, sets.New[string](v...))
+
+	if ok := bot.cli.CreatePRComment(c.org, c.repo, c.number,
+		fmt.Sprintf(commentRevokedLabel, strings.Join(v, ", "), source, how, c.commenter)); !ok {
+		return fmt.Errorf("failed to comment on pull request")
+	}
+	return nil
+}
+
+// recordRevokedReviews saves the review records of the revoked lgtm, approved and hold labels.
+func (bot *robot) recordRevokedReviews(c *commandContext, sources []commandSource, revoked sets.Set[string]) {
+	for i := range sources {
+		for _, l := range sources[i].labels {
+			if !revoked.Has(l) {
+				continue
+			}
+
+			r := reviewRecord{Org: c.org, Repo: c.repo, Number: c.number, User: sources[i].user, Label: l,
+				CommentID: sources[i].commentID}
+			switch {
+			case l == approvedLabel:
+				r.Command = reviewActionApproveCancel
+			case strings.HasPrefix(l, lgtmLabel):
+				r.Command = reviewActionLgtmCancel
+			case l == holdLabel:
+				r.Command = reviewActionUnhold
+			default:
+				continue
+			}
+			bot.saveReview(r)
+		}
+	}
+}
@@ -16,6 +16,8 @@ package main
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/opensourceways/server-common-lib/config"
 )
@@ -29,6 +31,20 @@ type configuration struct {
 	SigInfoURL string `json:"sig_info_url" required:"true"`
 	// Community name used as a request parameter to getRepoConfig sig information.
 	CommunityName string `json:"community_name" required:"true"`
+	// Reconciler merges the ready pull requests periodically in case the events are missed.
+	Reconciler reconcilerConfig `json:"reconciler,omitempty"`
+	// LifecycleInterval is the minutes between two rounds of checking the inactive pull requests.
+	// The default value is 60. It only works for the repositories whose lifecycle is configured.
+	LifecycleInterval int `json:"lifecycle_interval,omitempty"`
+}
+
+// reconcilerConfig is the configuration of the job which reconciles the open pull requests.
+type reconcilerConfig struct {
+	// Interval is the minutes between two rounds of reconciling. The job is disabled if it is 0.
+	Interval int `json:"interval,omitempty"`
+	// Repos specifies the repositories to reconcile, in the form of 'org/repo'.
+	// Each of them must be matched by one of the config items.
+	Repos []string `json:"repos,omitempty"`
 }
 
 // Validate to check the configmap data's validation, returns an error if invalid
@@ -45,9 +61,60 @@ func (c *configuration) Validate() error {
 		}
 	}
 
+	return c.validateReconciler()
+}
+
+// lifecycleRepos returns the repositories whose lifecycle is enabled. Only the ones in the form of 'org/repo'
+// are included, because the repositories of an organization are not listed.
+func (c *configuration) lifecycleRepos() []string {
+	var r []string
+	for i := range c.ConfigItems {
+		item := &c.ConfigItems[i]
+		if !item.Lifecycle.enabled() {
+			continue
+		}
+
+		for _, v := range item.Repos {
+			org, repo, ok := splitRepo(v)
+			// the repository may be excluded, or be matched by another item first
+			if ok && c.get(org, repo) == item {
+				r = append(r, v)
+			}
+		}
+	}
+
+	return r
+}
+
+func (c *configuration) validateReconciler() error {
+	if c.Reconciler.Interval < 0 {
+		return errors.New("the interval of reconciler can not be negative")
+	}
+
+	for _, v := range c.Reconciler.Repos {
+		org, repo, ok := splitRepo(v)
+		if !ok {
+			return fmt.Errorf("invalid repository of reconciler: %s, it should be org/repo", v)
+		}
+		if c.get(org, repo) == nil {
+			return fmt.Errorf("no config for the repository of reconciler: %s", v)
+		}
+	}
+
 	return nil
 }
 
+// splitRepo splits the repository in the form of 'org/repo' by the last slash,
+// since the org may contain slashes, such as a subgroup of GitLab.
+func splitRepo(v string) (org, repo string, ok bool) {
+	i := strings.LastIndex(v, "/")
+	if i <= 0 || i == len(v)-1 {
+		return "", "", false
+	}
+
+	return v[:i], v[i+1:], true
+}
+
 // get retrieves a repoConfig for a given organization and repository.
 // Returns the repoConfig if found, otherwise returns nil.
 func (c *configuration) get(org, repo string) *repoConfig {
@@ -70,8 +137,12 @@ func (c *configuration) get(org, repo string) *repoConfig {
 type repoConfig struct {
 	// RepoFilter is used to filter repositories.
 	config.RepoFilter
-	// LegalOperator means who can add or remove labels legally
+	// LegalOperator means who can add or remove labels legally.
+	// The default value is the user behind the token of the organization.
 	LegalOperator string `json:"legal_operator,omitempty"`
+	// TrustedOperators are the users besides the legal operator who can add the labels for merge,
+	// such as the bots of CI and CLA.
+	TrustedOperators []string `json:"trusted_operators,omitempty"`
 
 	// LgtmCountsRequired specifies the number of lgtm label which will be need for the pr.
 	// When it is greater than 1, the lgtm label is composed of 'lgtm-login'.
@@ -89,6 +160,141 @@ type repoConfig struct {
 	// MergeMethod is the method to merge PR.
 	// The default method of merge. Valid options are squash and merge.
 	MergeMethod string `json:"merge_method,omitempty"`
+
+	// AllowedMergeMethods specifies the methods which can be set by '/merge-method'.
+	// The default value is merge, squash and rebase.
+	AllowedMergeMethods []string `json:"allowed_merge_methods,omitempty"`
+
+	// DisabledCommands specifies the commands which can not be used in the repository.
+	// The command name is without the leading slash, such as 'rebase'.
+	DisabledCommands []string `json:"disabled_commands,omitempty"`
+
+	// ReviewerPool specifies the users which will be assigned when '/assign' is used without any user.
+	ReviewerPool []string `json:"reviewer_pool,omitempty"`
+
+	// ReviewerPoolAssignCount specifies how many users of ReviewerPool will be assigned at a time.
+	// The default value is 1.
+	ReviewerPoolAssignCount int `json:"reviewer_pool_assign_count,omitempty"`
+
+	// LabelPrefixesForAnyone specifies the prefixes of labels which anyone can add or remove
+	// by '/label' and '/remove-label', such as 'kind/'.
+	LabelPrefixesForAnyone []string `json:"label_prefixes_for_anyone,omitempty"`
+
+	// LabelsForMaintainers specifies the labels which only the collaborators can add or remove
+	// by '/label' and '/remove-label'.
+	LabelsForMaintainers []string `json:"labels_for_maintainers,omitempty"`
+
+	// LabelDescriptions specifies the descriptions of labels displayed in the report of '/check-pr'.
+	LabelDescriptions []labelDescription `json:"label_descriptions,omitempty"`
+
+	// WIPTitlePrefixes specifies the prefixes of title which mean the pull request is a work in progress.
+	// They are case-insensitive, and the default value is '[WIP]', 'WIP:' and 'WIP '.
+	WIPTitlePrefixes []string `json:"wip_title_prefixes,omitempty"`
+
+	// MergedSummary specifies whether to comment a summary when the pull request is merged.
+	// It tells whether the merge conditions were bypassed if it is merged outside the robot.
+	MergedSummary bool `json:"merged_summary,omitempty"`
+
+	// ReviewMaxAgeDays specifies how many days the lgtm and approved labels are valid since they were added.
+	// The expired ones are removed when the pull request is checked for merging. It is disabled if it is 0.
+	ReviewMaxAgeDays int `json:"review_max_age_days,omitempty"`
+
+	// Lifecycle specifies when the inactive pull requests are marked as stale or rotten, and closed.
+	Lifecycle lifecycleConfig `json:"lifecycle,omitempty"`
+
+	// NativeReviews specifies what the reviews submitted in the web page of the platform mean.
+	// They are ignored by default.
+	NativeReviews nativeReviewConfig `json:"native_reviews,omitempty"`
+}
+
+// lifecycleConfig specifies the days of inactivity of each step of the lifecycle.
+// Each step is counted from the last activity, including the previous step.
+type lifecycleConfig struct {
+	// StaleDays is the days before the pull request is marked as stale. The lifecycle is disabled if it is 0.
+	StaleDays int `json:"stale_days,omitempty"`
+	// RottenDays is the days before the stale pull request is marked as rotten.
+	RottenDays int `json:"rotten_days,omitempty"`
+	// CloseDays is the days before the rotten pull request is closed.
+	CloseDays int `json:"close_days,omitempty"`
+}
+
+func (c *lifecycleConfig) enabled() bool {
+	return c.StaleDays > 0
+}
+
+func (c *lifecycleConfig) validate() error {
+	if c.StaleDays < 0 || c.RottenDays < 0 || c.CloseDays < 0 {
+		return errors.New("the days of lifecycle can not be negative")
+	}
+	if c.enabled() && (c.RottenDays == 0 || c.CloseDays == 0) {
+		return errors.New("rotten_days and close_days of lifecycle must be set when stale_days is set")
+	}
+
+	return nil
+}
+
+// nativeReviewConfig maps the reviews submitted in the web page of the platform to the commands.
+// The values are lgtm, approve and block, which mean '/lgtm', '/approve' and '/hold'.
+// A review is ignored if its value is empty.
+type nativeReviewConfig struct {
+	// Approved is what an approving review means.
+	Approved string `json:"approved,omitempty"`
+	// ChangesRequested is what a review requesting changes means.
+	ChangesRequested string `json:"changes_requested,omitempty"`
+}
+
+func (c *nativeReviewConfig) validate() error {
+	for _, v := range []string{c.Approved, c.ChangesRequested} {
+		if _, ok := nativeReviewCommands[v]; v != "" && !ok {
+			return fmt.Errorf("unknown action of native_reviews: %s, it must be one of lgtm, approve and block", v)
+		}
+	}
+
+	return nil
+}
+
+// actionOf returns what the review in the state means.
+func (c *nativeReviewConfig) actionOf(state string) string {
+	switch state {
+	case reviewStateApproved:
+		return c.Approved
+	case reviewStateChangesRequested:
+		return c.ChangesRequested
+	}
+
+	return ""
+}
+
+// labelDescription describes a label in the report of '/check-pr'.
+type labelDescription struct {
+	// Label is the name of the label.
+	Label string `json:"label" required:"true"`
+	// Description tells what the label means.
+	Description string `json:"description,omitempty"`
+	// Fixer tells who can add or remove the label and how to do it.
+	Fixer string `json:"fixer,omitempty"`
+}
+
+// labelDescription returns the description of the label, or the default one if it is not configured.
+func (c *repoConfig) labelDescription(label, def string) string {
+	for i := range c.LabelDescriptions {
+		if d := &c.LabelDescriptions[i]; d.Label == label && d.Description != "" {
+			return d.Description
+		}
+	}
+
+	return def
+}
+
+// labelFixer returns who can fix the label, or the default one if it is not configured.
+func (c *repoConfig) labelFixer(label, def string) string {
+	for i := range c.LabelDescriptions {
+		if d := &c.LabelDescriptions[i]; d.Label == label && d.Fixer != "" {
+			return d.Fixer
+		}
+	}
+
+	return def
 }
 
 type freezeFile struct {
@@ -147,9 +353,114 @@ func (c *repoConfig) validate() error {
 		return errors.New("the repositories configuration can not be empty")
 	}
 
+	if c.ReviewMaxAgeDays < 0 {
+		return errors.New("review_max_age_days can not be negative")
+	}
+
+	if err := c.Lifecycle.validate(); err != nil {
+		return err
+	}
+
+	if err := c.NativeReviews.validate(); err != nil {
+		return err
+	}
+
+	if err := c.validateDisabledCommands(); err != nil {
+		return err
+	}
+
+	if err := c.validateAllowedMergeMethods(); err != nil {
+		return err
+	}
+
 	return c.RepoFilter.Validate()
 }
 
+// allowedMergeMethods returns the methods which can be used to merge the pull request.
+func (c *repoConfig) allowedMergeMethods() []string {
+	if len(c.AllowedMergeMethods) == 0 {
+		return []string{mergeMethodMerge, mergeMethodSquash, mergeMethodRebase}
+	}
+
+	return c.AllowedMergeMethods
+}
+
+// wipTitlePrefixes returns the prefixes of title which mean the pull request is a work in progress.
+func (c *repoConfig) wipTitlePrefixes() []string {
+	if len(c.WIPTitlePrefixes) == 0 {
+		return defaultWIPTitlePrefixes
+	}
+
+	return c.WIPTitlePrefixes
+}
+
+// canAddLabel checks whether the user can add the protected label legally.
+func (c *repoConfig) canAddLabel(legalOperator, user, label string) bool {
+	if user == legalOperator {
+		return true
+	}
+
+	return slices.Contains(c.LabelsForMerge, label) && slices.Contains(c.TrustedOperators, user)
+}
+
+// isCommandEnabled checks whether the command can be used in the repository by the name, which may be
+// an alias of it. Disabling an alias only disables calling the command by it.
+func (c *repoConfig) isCommandEnabled(cmd *command, name string) bool {
+	for _, v := range c.DisabledCommands {
+		v = strings.TrimPrefix(v, "/")
+		if strings.EqualFold(v, cmd.name) || strings.EqualFold(v, name) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateDisabledCommands rejects the unknown commands, which would be ignored silently.
+func (c *repoConfig) validateDisabledCommands() error {
+	for _, v := range c.DisabledCommands {
+		if commands.lookup(strings.TrimPrefix(v, "/")) == nil {
+			return fmt.Errorf("unknown command of disabled_commands: %s", v)
+		}
+	}
+
+	return nil
+}
+
+// validateAllowedMergeMethods rejects the unknown methods, with which the pull requests can't be merged.
+func (c *repoConfig) validateAllowedMergeMethods() error {
+	for _, v := range c.AllowedMergeMethods {
+		if !slices.Contains(allMergeMethods, v) {
+			return fmt.Errorf("unknown method of allowed_merge_methods: %s, it must be one of %s",
+				v, strings.Join(allMergeMethods, ", "))
+		}
+	}
+
+	return nil
+}
+
+// isLabelForAnyone checks whether the label can be changed by anyone.
+func (c *repoConfig) isLabelForAnyone(label string) bool {
+	for _, v := range c.LabelPrefixesForAnyone {
+		if strings.HasPrefix(label, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isLabelForMaintainers checks whether the label can be changed by the collaborators.
+func (c *repoConfig) isLabelForMaintainers(label string) bool {
+	for _, v := range c.LabelsForMaintainers {
+		if v == label {
+			return true
+		}
+	}
+
+	return false
+}
+
 type litePRCommiter struct {
 	// Email is the one of committer in a commit when a PR is lite
 	Email string `json:"email" required:"true"`
@@ -0,0 +1,59 @@
+package main
+
+import "testing"
+
+func TestValidateDisabledCommands(t *testing.T) {
+	cases := []struct {
+		disabled []string
+		valid    bool
+	}{
+		{disabled: []string{"/rebase", "lgtm"}, valid: true},
+		{disabled: []string{"merge-method"}, valid: true},
+		{disabled: []string{"unknown"}, valid: false},
+	}
+
+	for _, c := range cases {
+		cnf := repoConfig{DisabledCommands: c.disabled}
+		if err := cnf.validateDisabledCommands(); (err == nil) != c.valid {
+			t.Errorf("%v: unexpected error: %v", c.disabled, err)
+		}
+	}
+}
+
+func TestSplitRepo(t *testing.T) {
+	cases := []struct {
+		v, org, repo string
+		ok           bool
+	}{
+		{v: "org/repo", org: "org", repo: "repo", ok: true},
+		{v: "group/subgroup/repo", org: "group/subgroup", repo: "repo", ok: true},
+		{v: "org"},
+		{v: "org/"},
+		{v: "/repo"},
+	}
+
+	for _, c := range cases {
+		org, repo, ok := splitRepo(c.v)
+		if org != c.org || repo != c.repo || ok != c.ok {
+			t.Errorf("%s: unexpected result: %s, %s, %t", c.v, org, repo, ok)
+		}
+	}
+}
+
+func TestValidateAllowedMergeMethods(t *testing.T) {
+	cases := []struct {
+		methods []string
+		valid   bool
+	}{
+		{valid: true},
+		{methods: []string{mergeMethodMerge, mergeMethodSquash}, valid: true},
+		{methods: []string{"sqash"}, valid: false},
+	}
+
+	for _, c := range cases {
+		cnf := repoConfig{AllowedMergeMethods: c.methods}
+		if err := cnf.validateAllowedMergeMethods(); (err == nil) != c.valid {
+			t.Errorf("%v: unexpected error: %v", c.methods, err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/opensourceways/robot-framework-lib/utils"
+)
+
+const (
+	defaultDeliveryTTL      = 10 * time.Minute
+	defaultDeliveryCapacity = 10000
+)
+
+type delivery struct {
+	key     string
+	expired time.Time
+}
+
+// deliveryCache remembers the recent webhook deliveries, so that the ones retried by the platform are handled once.
+// The entries expire after the ttl, and the oldest ones are evicted when the capacity is exceeded.
+type deliveryCache struct {
+	lock     sync.Mutex
+	ttl      time.Duration
+	capacity int
+	// order holds the deliveries in the order of arrival, which is also the order of expiration.
+	order *list.List
+	index map[string]*list.Element
+}
+
+func newDeliveryCache(ttl time.Duration, capacity int) *deliveryCache {
+	if ttl <= 0 {
+		ttl = defaultDeliveryTTL
+	}
+	if capacity <= 0 {
+		capacity = defaultDeliveryCapacity
+	}
+
+	return &deliveryCache{
+		ttl:      ttl,
+		capacity: capacity,
+		order:    list.New(),
+		index:    map[string]*list.Element{},
+	}
+}
+
+// seen records the delivery of the event, and returns true if it has been recorded before.
+func (c *deliveryCache) seen(evt *client.GenericEvent) bool {
+	key := deliveryKey(evt)
+	if key == "" {
+		return false
+	}
+
+	now := time.Now()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.evict(now)
+
+	if _, ok := c.index[key]; ok {
+		return true
+	}
+
+	c.index[key] = c.order.PushBack(&delivery{key: key, expired: now.Add(c.ttl)})
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Front())
+	}
+
+	return false
+}
+
+func (c *deliveryCache) evict(now time.Time) {
+	for e := c.order.Front(); e != nil; e = c.order.Front() {
+		if e.Value.(*delivery).expired.After(now) {
+			return
+		}
+		c.remove(e)
+	}
+}
+
+func (c *deliveryCache) remove(e *list.Element) {
+	delete(c.index, e.Value.(*delivery).key)
+	c.order.Remove(e)
+}
+
+// deliveryKey identifies the delivery of the event by its delivery id,
+// or by the hash of the content if the id is not given.
+func deliveryKey(evt *client.GenericEvent) string {
+	if id := utils.GetString(evt.EventGUID); id != "" {
+		return "id:" + id
+	}
+
+	var data []byte
+	if payload := evt.GetMetaPayload(); payload != nil && payload.Len() > 0 {
+		data = payload.Bytes()
+	} else {
+		var err error
+		if data, err = json.Marshal(evt); err != nil {
+			return ""
+		}
+	}
+
+	h := sha256.Sum256(data)
+	return "hash:" + hex.EncodeToString(h[:])
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"runtime/debug"
+	"sync"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/opensourceways/robot-framework-lib/config"
+	"github.com/opensourceways/robot-framework-lib/framework"
+	"github.com/opensourceways/robot-framework-lib/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultEventWorkers = 8
+
+type prJob struct {
+	run func()
+	// evt is the event handled by the job, it is nil if the job isn't run for an event
+	evt  *client.GenericEvent
+	done chan struct{}
+}
+
+// prDispatcher runs the jobs of the same pull request one by one in the order of arrival,
+// and runs the ones of different pull requests in parallel by a bounded pool of workers.
+// Otherwise, a merge check may read the labels while they are being changed by a command.
+type prDispatcher struct {
+	lock sync.Mutex
+	// queues holds the jobs of each pull request, the first one is being run.
+	queues  map[string][]*prJob
+	pending int
+	// keys passes the pull requests which have jobs to the idle workers. It is unbuffered on purpose,
+	// so the caller of run blocks until a worker is idle, which bounds the events in flight by the workers.
+	// The caller waits for its job anyway, so it blocks no longer than it would with a buffer.
+	keys chan string
+}
+
+func newPRDispatcher(workers int) *prDispatcher {
+	if workers <= 0 {
+		workers = defaultEventWorkers
+	}
+
+	d := &prDispatcher{
+		queues: map[string][]*prJob{},
+		keys:   make(chan string),
+	}
+	for i := 0; i < workers; i++ {
+		go d.work()
+	}
+
+	return d
+}
+
+// wrap makes the handler run by the dispatcher.
+func (d *prDispatcher) wrap(fn framework.GenericHandlerFunc) framework.GenericHandlerFunc {
+	return func(evt *client.GenericEvent, cnf config.Configmap, logger *logrus.Entry) {
+		key := prKey(utils.GetString(evt.Org), utils.GetString(evt.Repo), utils.GetString(evt.Number))
+		d.submit(key, logger, &prJob{run: func() { fn(evt, cnf, logger) }, evt: evt, done: make(chan struct{})})
+	}
+}
+
+// run queues the job of the pull request and waits until it is done,
+// so that the graceful shutdown of the framework still waits for it.
+// It must not be called by a job of the same pull request, which would wait for itself forever.
+func (d *prDispatcher) run(key string, logger *logrus.Entry, fn func()) {
+	d.submit(key, logger, &prJob{run: fn, done: make(chan struct{})})
+}
+
+func (d *prDispatcher) submit(key string, logger *logrus.Entry, job *prJob) {
+	d.lock.Lock()
+	q := append(d.queues[key], job)
+	d.queues[key] = q
+	d.pending++
+	pending := d.pending
+	d.lock.Unlock()
+
+	fields := logrus.Fields{"pr_queue_depth": len(q), "queue_depth": pending}
+	if len(q) > 1 {
+		logger.WithFields(fields).Info("the event waits for the previous ones of the pull request")
+	} else {
+		logger.WithFields(fields).Debug("the event is queued")
+		// only the first job schedules the pull request, the others are run by the same worker after it
+		d.keys <- key
+	}
+
+	<-job.done
+}
+
+// drop discards the jobs of the pull request waiting behind the running one, except the ones whose events
+// are kept by keep, such as it is reopened. It is called when the pull request is closed, so the stale
+// events don't work on it any more.
+func (d *prDispatcher) drop(key string, keep func(*client.GenericEvent) bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	q := d.queues[key]
+	if len(q) <= 1 {
+		return
+	}
+
+	kept := q[:1]
+	for _, job := range q[1:] {
+		if job.evt != nil && keep(job.evt) {
+			kept = append(kept, job)
+			continue
+		}
+
+		d.pending--
+		close(job.done)
+	}
+	d.queues[key] = kept
+}
+
+func (d *prDispatcher) work() {
+	for key := range d.keys {
+		for {
+			d.lock.Lock()
+			job := d.queues[key][0]
+			d.lock.Unlock()
+
+			d.runJob(key, job)
+
+			d.lock.Lock()
+			d.pending--
+			q := d.queues[key][1:]
+			if len(q) == 0 {
+				delete(d.queues, key)
+			} else {
+				d.queues[key] = q
+			}
+			d.lock.Unlock()
+
+			if len(q) == 0 {
+				break
+			}
+		}
+	}
+}
+
+// runJob runs the job and marks it done even if it panics, so neither its caller
+// nor the jobs queued after it of the pull request are stuck.
+func (d *prDispatcher) runJob(key string, job *prJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.Errorf("the job of %s panics: %v\n%s", key, r, debug.Stack())
+		}
+		close(job.done)
+	}()
+
+	job.run()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/opensourceways/robot-framework-lib/utils"
+	"github.com/sirupsen/logrus"
+)
+
+func TestDispatcherRecoversPanic(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	entry := logrus.NewEntry(logger)
+
+	d := newPRDispatcher(1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		d.run("org/repo/1", entry, func() { panic("broken handler") })
+
+		ran := false
+		d.run("org/repo/1", entry, func() { ran = true })
+		if !ran {
+			t.Error("the job after the panic is not run")
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the dispatcher is stuck after a panic")
+	}
+}
+
+func TestDispatcherDrop(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	entry := logrus.NewEntry(logger)
+
+	const key = "org/repo/1"
+	d := newPRDispatcher(1)
+	started, release := make(chan struct{}), make(chan struct{})
+	go d.run(key, entry, func() {
+		close(started)
+		<-release
+		d.drop(key, func(evt *client.GenericEvent) bool {
+			return utils.GetString(evt.Action) == eventActionReopen
+		})
+	})
+	<-started
+
+	var labeled, reopened bool
+	done := make(chan struct{})
+	submit := func(action string, ran *bool) {
+		evt := &client.GenericEvent{Action: newString(action)}
+		d.submit(key, entry, &prJob{run: func() { *ran = true }, evt: evt, done: make(chan struct{})})
+		done <- struct{}{}
+	}
+	go submit(eventActionUpdate, &labeled)
+	for d.depth(key) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	go submit(eventActionReopen, &reopened)
+	for d.depth(key) < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the dispatcher is stuck after dropping the jobs")
+		}
+	}
+	if labeled || !reopened {
+		t.Errorf("unexpected jobs run, the labeled one: %t, the reopened one: %t", labeled, reopened)
+	}
+}
+
+// depth returns the number of the jobs of the pull request, including the running one.
+func (d *prDispatcher) depth(key string) int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return len(d.queues[key])
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/opensourceways/robot-framework-lib/utils"
+)
+
+const (
+	noteActionUpdate = "update"
+	noteActionDelete = "delete"
+)
+
+// notePayload is the part of the comment event payload which GenericEvent doesn't carry.
+type notePayload struct {
+	Attributes struct {
+		Action string `json:"action"`
+	} `json:"object_attributes"`
+}
+
+// getNoteAction returns the action on the comment, such as update and delete.
+// An empty string means the comment is created. The events translated from the other platforms
+// keep it as the ActionDetail, which is always empty for the notes of GitCode.
+func getNoteAction(evt *client.GenericEvent) string {
+	if v := utils.GetString(evt.ActionDetail); v != "" {
+		return v
+	}
+
+	payload := evt.GetMetaPayload()
+	if payload == nil {
+		return ""
+	}
+
+	var p notePayload
+	if err := json.Unmarshal(payload.Bytes(), &p); err != nil {
+		return ""
+	}
+
+	return p.Attributes.Action
+}
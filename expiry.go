@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const commentReviewExpired = `***%s*** %s removed because %s added more than %d days ago, ` +
+	`and the code may be out of date with the target branch. :hourglass:
+Please review this pull request again.`
+
+// expireReviewLabels removes the lgtm and approved labels which are older than the max age,
+// and returns the removed ones. The age is measured from when the label was added by the operation log.
+func (bot *robot) expireReviewLabels(
+	configmap *repoConfig, org, repo, number string, labels sets.Set[string], ops []client.PullRequestOperationLog,
+) ([]string, error) {
+	if configmap.ReviewMaxAgeDays <= 0 {
+		return nil, nil
+	}
+
+	maxAge := time.Duration(configmap.ReviewMaxAgeDays) * day
+	var expired []string
+	for _, l := range reviewLabels(labels) {
+		if age, ok := labelAge(ops, l); ok && age > maxAge {
+			expired = append(expired, l)
+		}
+	}
+	if len(expired) == 0 {
+		return nil, nil
+	}
+
+	logrus.Infof("expireReviewLabels, labels: %v, org: %s, repo: %s, number: %s", expired, org, repo, number)
+	// the records are saved before removing, so that the removing is not taken as by hand
+	for _, l := range expired {
+		r := reviewRecord{Org: org, Repo: repo, Number: number, Command: reviewActionLgtmCancel, Label: l}
+		if l == approvedLabel {
+			r.Command = reviewActionApproveCancel
+		}
+		bot.saveReview(r)
+	}
+
+	if ok := bot.cli.RemovePRLabels(org, repo, number, expired); !ok {
+		return nil, fmt.Errorf("failed to remove label on pull request")
+	}
+
+	verb, pron := "was", "it was"
+	if len(expired) > 1 {
+		verb, pron = "were", "they were"
+	}
+	bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentReviewExpired,
+		strings.Join(expired, ", "), verb, pron, configmap.ReviewMaxAgeDays))
+
+	return expired, nil
+}
+
+// reviewLabels returns the lgtm and approved labels in order.
+func reviewLabels(labels sets.Set[string]) []string {
+	v := sets.List(sets.New[string](getLGTMLabelsOnPR(labels)...))
+	if labels.Has(approvedLabel) {
+		v = append(v, approvedLabel)
+	}
+
+	return v
+}
+
+// labelAge returns how long it is since the label was added.
+func labelAge(ops []client.PullRequestOperationLog, label string) (time.Duration, bool) {
+	log, ok := getLatestLog(ops, label)
+	if !ok {
+		return 0, false
+	}
+
+	return time.Since(log.t), true
+}
+
+func formatAge(age time.Duration) string {
+	if d := int(age / day); d > 0 {
+		return fmt.Sprintf("%d days", d)
+	}
+	if h := int(age / time.Hour); h > 0 {
+		return fmt.Sprintf("%d hours", h)
+	}
+
+	return "less than an hour"
+}
@@ -0,0 +1,582 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/opensourceways/robot-framework-lib/framework"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	fakePRStateOpen   = "open"
+	fakePRStateClosed = "closed"
+	fakePRStateMerged = "merged"
+)
+
+// fakeCapabilities is what the fake forge supports by default, which has no limits.
+var fakeCapabilities = capabilities{
+	mergeMethods: allMergeMethods,
+	reactions:    true,
+	draft:        true,
+}
+
+// fakeComment is a comment on a pull request of the fake forge.
+type fakeComment struct {
+	client.PRComment
+	User      string
+	Reactions []string
+}
+
+// fakePullRequest is a pull request of the fake forge.
+type fakePullRequest struct {
+	org, repo, number string
+
+	title       string
+	author      string
+	draft       bool
+	headSHA     string
+	state       string
+	mergedBy    string
+	mergeMethod string
+	updatedAt   time.Time
+
+	labels    sets.Set[string]
+	ops       []client.PullRequestOperationLog
+	comments  []fakeComment
+	commits   []client.PRCommit
+	files     []client.CommitFile
+	assignees sets.Set[string]
+	reviewers sets.Set[string]
+}
+
+func (pr *fakePullRequest) info() pullRequestInfo {
+	return pullRequestInfo{
+		Number:    pr.number,
+		Title:     pr.title,
+		Author:    pr.author,
+		Draft:     pr.draft,
+		HeadSHA:   pr.headSHA,
+		MergedBy:  pr.mergedBy,
+		Closed:    pr.state != fakePRStateOpen,
+		Labels:    sets.List(pr.labels),
+		UpdatedAt: pr.updatedAt,
+	}
+}
+
+// fakeForge is an in-memory code hosting platform which implements iClient. It behaves like the real ones:
+// the labels are logged with the user and the time, only the collaborators can merge, and every change
+// produces a webhook event, including the ones made by the robot. The events are queued until they are taken.
+type fakeForge struct {
+	genericEvents
+
+	lock sync.Mutex
+	// robot is the user of the token of the robot
+	robot string
+	// collaborators is the users who can write each repository, the key is 'org/repo'
+	collaborators map[string]sets.Set[string]
+	// cla is the sign state of each url of CLA
+	cla    map[string]string
+	prs    map[string]*fakePullRequest
+	events []*client.GenericEvent
+	nextID int
+}
+
+func newFakeForge(robot string) *fakeForge {
+	return &fakeForge{
+		robot:         robot,
+		collaborators: map[string]sets.Set[string]{},
+		cla:           map[string]string{},
+		prs:           map[string]*fakePullRequest{},
+	}
+}
+
+func (f *fakeForge) newID() string {
+	f.nextID++
+	return strconv.Itoa(f.nextID)
+}
+
+// addCollaborators grants the users the permission to write the repository.
+func (f *fakeForge) addCollaborators(org, repo string, users ...string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	key := org + "/" + repo
+	if f.collaborators[key] == nil {
+		f.collaborators[key] = sets.New[string]()
+	}
+	f.collaborators[key].Insert(users...)
+}
+
+// setCLA sets the sign state of CLA returned for the url.
+func (f *fakeForge) setCLA(urlStr, signState string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.cla[urlStr] = signState
+}
+
+// openPR creates a pull request whose number is returned.
+func (f *fakeForge) openPR(org, repo, author, title string) string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	pr := &fakePullRequest{
+		org:       org,
+		repo:      repo,
+		number:    f.newID(),
+		title:     title,
+		author:    author,
+		headSHA:   f.newID(),
+		state:     fakePRStateOpen,
+		updatedAt: time.Now(),
+		labels:    sets.New[string](),
+		assignees: sets.New[string](),
+		reviewers: sets.New[string](),
+	}
+	f.prs[prKey(org, repo, pr.number)] = pr
+	f.emitPR(pr, eventActionOpen, "")
+
+	return pr.number
+}
+
+// pushCommits replaces the change of the pull request, and the head commit is changed.
+func (f *fakeForge) pushCommits(org, repo, number string, files []client.CommitFile, commits ...client.PRCommit) {
+	f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		pr.files = files
+		pr.commits = append(pr.commits, commits...)
+		pr.headSHA = f.newID()
+		pr.updatedAt = time.Now()
+		f.emitPR(pr, eventActionUpdate, eventDetailSourceUpdate)
+	})
+}
+
+// setTitle changes the title and the draft state by the author.
+func (f *fakeForge) setTitle(org, repo, number, title string, draft bool) {
+	f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		pr.title, pr.draft = title, draft
+		pr.updatedAt = time.Now()
+		f.emitPR(pr, eventActionUpdate, "")
+	})
+}
+
+// comment posts a comment by the user. The comments of the robot don't produce events,
+// which is the same as the framework does.
+func (f *fakeForge) comment(org, repo, number, user, body string) string {
+	var id string
+	f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		id = f.newID()
+		pr.comments = append(pr.comments, fakeComment{PRComment: client.PRComment{ID: id, Body: body}, User: user})
+		if user != f.robot {
+			f.emitNote(pr, id, user, body, "")
+		}
+	})
+
+	return id
+}
+
+// editComment changes the body of the comment, an empty body means the comment is deleted.
+func (f *fakeForge) editComment(org, repo, number, commentID, body string) {
+	f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		for i := range pr.comments {
+			c := &pr.comments[i]
+			if c.ID != commentID {
+				continue
+			}
+
+			if body == "" {
+				f.emitNote(pr, c.ID, c.User, c.Body, noteActionDelete)
+				pr.comments = append(pr.comments[:i], pr.comments[i+1:]...)
+				return
+			}
+			c.Body = body
+			f.emitNote(pr, c.ID, c.User, body, noteActionUpdate)
+			return
+		}
+	})
+}
+
+// review submits a review in the state by the user, or dismisses the review by the user if the state
+// is reviewStateDismissed. It returns the id of the submitted review.
+func (f *fakeForge) review(org, repo, number, user, state, reviewID string) string {
+	f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		if state != reviewStateDismissed {
+			reviewID = f.newID()
+		}
+
+		evt := f.newEvent(pr, framework.PullRequestEvent)
+		evt.Action = newString(eventActionReview)
+		evt.ActionDetail = newString(state)
+		evt.CommentID = newString(reviewID)
+		evt.Commenter = newString(user)
+		f.events = append(f.events, evt)
+	})
+
+	return reviewID
+}
+
+// changeLabels adds or removes the labels by the user, which is how the users change them in the web page.
+func (f *fakeForge) changeLabels(org, repo, number, user string, labels []string, add bool) bool {
+	success := false
+	f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		success = f.changeLabelsLocked(pr, user, labels, add)
+	})
+
+	return success
+}
+
+func (f *fakeForge) changeLabelsLocked(pr *fakePullRequest, user string, labels []string, add bool) bool {
+	// the labels of the closed pull requests can be changed too
+	if len(labels) == 0 {
+		return false
+	}
+
+	action, op := ActionRemoveLabel, "remove_label"
+	if add {
+		action, op = ActionAddLabel, "add_label"
+	}
+
+	now := time.Now()
+	changed := false
+	for _, l := range labels {
+		if add == pr.labels.Has(l) {
+			continue
+		}
+		if add {
+			pr.labels.Insert(l)
+		} else {
+			pr.labels.Delete(l)
+		}
+		pr.ops = append(pr.ops, client.PullRequestOperationLog{
+			CreatedAt: now,
+			UpdatedAt: now,
+			Content:   action + " " + l,
+			Action:    op,
+			UserName:  user,
+		})
+		changed = true
+	}
+	if changed {
+		pr.updatedAt = now
+		f.emitPR(pr, eventActionUpdate, eventDetailUpdateLabel)
+	}
+
+	return true
+}
+
+// close closes or merges the pull request by the user.
+func (f *fakeForge) close(org, repo, number, user string, merge bool) bool {
+	success := false
+	f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		success = f.closeLocked(pr, user, merge)
+	})
+
+	return success
+}
+
+func (f *fakeForge) closeLocked(pr *fakePullRequest, user string, merge bool) bool {
+	if pr.state != fakePRStateOpen {
+		return false
+	}
+
+	pr.updatedAt = time.Now()
+	if merge {
+		pr.state, pr.mergedBy = fakePRStateMerged, user
+		f.emitPR(pr, eventActionMerge, "")
+	} else {
+		pr.state = fakePRStateClosed
+		f.emitPR(pr, eventActionClose, "")
+	}
+
+	return true
+}
+
+// age moves the time of the pull request and its operation logs back, as if the time passed.
+func (f *fakeForge) age(org, repo, number string, d time.Duration) {
+	f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		pr.updatedAt = pr.updatedAt.Add(-d)
+		for i := range pr.ops {
+			pr.ops[i].CreatedAt = pr.ops[i].CreatedAt.Add(-d)
+			pr.ops[i].UpdatedAt = pr.ops[i].UpdatedAt.Add(-d)
+		}
+	})
+}
+
+// takeEvents returns the queued events and clears the queue.
+func (f *fakeForge) takeEvents() []*client.GenericEvent {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	v := f.events
+	f.events = nil
+
+	return v
+}
+
+// pullRequest returns a copy of the state of the pull request.
+func (f *fakeForge) pullRequest(org, repo, number string) (fakePullRequest, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	pr, ok := f.prs[prKey(org, repo, number)]
+	if !ok {
+		return fakePullRequest{}, false
+	}
+
+	v := *pr
+	v.labels = pr.labels.Clone()
+	v.ops = append([]client.PullRequestOperationLog(nil), pr.ops...)
+	v.comments = append([]fakeComment(nil), pr.comments...)
+	v.assignees = pr.assignees.Clone()
+	v.reviewers = pr.reviewers.Clone()
+
+	return v, true
+}
+
+func (f *fakeForge) withPR(org, repo, number string, fn func(pr *fakePullRequest)) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	pr, ok := f.prs[prKey(org, repo, number)]
+	if ok {
+		fn(pr)
+	}
+
+	return ok
+}
+
+func (f *fakeForge) newEvent(pr *fakePullRequest, eventType string) *client.GenericEvent {
+	state := eventStateOpened
+	switch pr.state {
+	case fakePRStateClosed:
+		state = eventStateClosed
+	case fakePRStateMerged:
+		state = eventStateMerged
+	}
+
+	evt := &client.GenericEvent{}
+	evt.EventType = newString(eventType)
+	evt.EventGUID = newString(fmt.Sprintf("fake-%s", f.newID()))
+	evt.State = newString(state)
+	evt.Org = newString(pr.org)
+	evt.Repo = newString(pr.repo)
+	evt.Number = newString(pr.number)
+	evt.Author = newString(pr.author)
+
+	return evt
+}
+
+func (f *fakeForge) emitPR(pr *fakePullRequest, action, detail string) {
+	evt := f.newEvent(pr, framework.PullRequestEvent)
+	evt.Action = newString(action)
+	evt.ActionDetail = newString(detail)
+	f.events = append(f.events, evt)
+}
+
+func (f *fakeForge) emitNote(pr *fakePullRequest, commentID, user, body, noteAction string) {
+	evt := f.newEvent(pr, framework.NoteEvent)
+	if pr.state != fakePRStateOpen {
+		// the same as the events translated from the other platforms
+		evt.State = newString(eventStateClosed)
+	}
+	evt.ActionDetail = newString(noteAction)
+	evt.CommentID = newString(commentID)
+	evt.CommentKind = newString(client.CommentOnPR)
+	evt.Comment = newString(body)
+	evt.Commenter = newString(user)
+	f.events = append(f.events, evt)
+}
+
+func (f *fakeForge) CreatePRComment(org, repo, number, comment string) (success bool) {
+	return f.comment(org, repo, number, f.robot, comment) != ""
+}
+
+func (f *fakeForge) AddPRLabels(org, repo, number string, labels []string) (success bool) {
+	return f.changeLabels(org, repo, number, f.robot, labels, true)
+}
+
+func (f *fakeForge) RemovePRLabels(org, repo, number string, labels []string) (success bool) {
+	return f.changeLabels(org, repo, number, f.robot, labels, false)
+}
+
+func (f *fakeForge) GetPullRequestCommits(org, repo, number string) (result []client.PRCommit, success bool) {
+	success = f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		result = append(result, pr.commits...)
+	})
+	return
+}
+
+func (f *fakeForge) ListPullRequestComments(org, repo, number string) (result []client.PRComment, success bool) {
+	success = f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		for i := range pr.comments {
+			result = append(result, pr.comments[i].PRComment)
+		}
+	})
+	return
+}
+
+func (f *fakeForge) DeletePRComment(org, repo, commentID string) (success bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, pr := range f.prs {
+		if pr.org != org || pr.repo != repo {
+			continue
+		}
+		for i := range pr.comments {
+			if pr.comments[i].ID == commentID {
+				pr.comments = append(pr.comments[:i], pr.comments[i+1:]...)
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (f *fakeForge) CheckCLASignature(urlStr string) (signState string, success bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if v, ok := f.cla[urlStr]; ok {
+		return v, true
+	}
+
+	return client.CLASignStateUnknown, false
+}
+
+func (f *fakeForge) CheckPermission(org, repo, username string) (pass, success bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.collaborators[org+"/"+repo].Has(username), true
+}
+
+func (f *fakeForge) GetPullRequestLabels(org, repo, number string) (result []string, success bool) {
+	success = f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		result = sets.List(pr.labels)
+	})
+	return
+}
+
+// MergePullRequest merges the pull request if the robot is a collaborator, as the real platforms require.
+func (f *fakeForge) MergePullRequest(org, repo, number, mergeMethod string) (success bool) {
+	f.lock.Lock()
+	canMerge := f.collaborators[org+"/"+repo].Has(f.robot)
+	f.lock.Unlock()
+	if !canMerge {
+		return false
+	}
+
+	f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		if success = f.closeLocked(pr, f.robot, true); success {
+			pr.mergeMethod = mergeMethod
+		}
+	})
+	return
+}
+
+func (f *fakeForge) ListPullRequestOperationLogs(org, repo, number string) (
+	result []client.PullRequestOperationLog, success bool,
+) {
+	success = f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		result = append(result, pr.ops...)
+	})
+	return
+}
+
+func (f *fakeForge) GetPullRequestChanges(org, repo, number string) (result []client.CommitFile, success bool) {
+	success = f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		result = append(result, pr.files...)
+	})
+	return
+}
+
+func (f *fakeForge) AssignPullRequest(org, repo, number string, logins []string) (success bool) {
+	return len(logins) > 0 && f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		pr.assignees.Insert(logins...)
+	})
+}
+
+func (f *fakeForge) UnassignPullRequest(org, repo, number string, logins []string) (success bool) {
+	return len(logins) > 0 && f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		pr.assignees.Delete(logins...)
+	})
+}
+
+func (f *fakeForge) RequestPullRequestReviewers(org, repo, number string, logins []string) (success bool) {
+	return len(logins) > 0 && f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		pr.reviewers.Insert(logins...)
+	})
+}
+
+func (f *fakeForge) AddCommentReaction(org, repo, number, commentID, reaction string) (success bool) {
+	f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		for i := range pr.comments {
+			if c := &pr.comments[i]; c.ID == commentID {
+				c.Reactions = append(c.Reactions, reaction)
+				success = true
+				return
+			}
+		}
+	})
+	return
+}
+
+func (f *fakeForge) GetCurrentUser() (login string, success bool) {
+	return f.robot, true
+}
+
+func (f *fakeForge) ClosePullRequest(org, repo, number string) (success bool) {
+	return f.close(org, repo, number, f.robot, false)
+}
+
+func (f *fakeForge) ReopenPullRequest(org, repo, number string) (success bool) {
+	f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		if pr.state != fakePRStateClosed {
+			return
+		}
+		pr.state = fakePRStateOpen
+		pr.updatedAt = time.Now()
+		f.emitPR(pr, eventActionReopen, "")
+		success = true
+	})
+	return
+}
+
+func (f *fakeForge) GetPullRequestInfo(org, repo, number string) (result pullRequestInfo, success bool) {
+	success = f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		result = pr.info()
+	})
+	return
+}
+
+func (f *fakeForge) UpdatePullRequestTitle(org, repo, number, title string) (success bool) {
+	return f.withPR(org, repo, number, func(pr *fakePullRequest) {
+		pr.title = title
+		pr.updatedAt = time.Now()
+		f.emitPR(pr, eventActionUpdate, "")
+	})
+}
+
+func (f *fakeForge) ListOpenPullRequests(org, repo string) (result []pullRequestInfo, success bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, pr := range f.prs {
+		if pr.org == org && pr.repo == repo && pr.state == fakePRStateOpen {
+			result = append(result, pr.info())
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, _ := strconv.Atoi(result[i].Number)
+		b, _ := strconv.Atoi(result[j].Number)
+		return a < b
+	})
+
+	return result, true
+}
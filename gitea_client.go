@@ -0,0 +1,458 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	giteaAPIBaseURL   = "https://gitea.com/api/v1/"
+	forgejoAPIBaseURL = "https://codeberg.org/api/v1/"
+
+	// giteaPageSize is the default max number of the items of a page, which is MAX_RESPONSE_ITEMS
+	giteaPageSize = 50
+	// giteaLabelColor is the color of the labels created by the robot
+	giteaLabelColor = "#ededed"
+	// giteaTimelineLabel is the type of the timeline comments of adding and removing labels
+	giteaTimelineLabel = "label"
+	// giteaLabelAdded is the content of the timeline comment when the label is added, it is empty if removed
+	giteaLabelAdded = "1"
+)
+
+type giteaLabel struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type giteaPullRequest struct {
+	Number    int64        `json:"number"`
+	Title     string       `json:"title"`
+	State     string       `json:"state"`
+	Draft     bool         `json:"draft"`
+	User      githubUser   `json:"user"`
+	MergedBy  *githubUser  `json:"merged_by"`
+	Labels    []giteaLabel `json:"labels"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	Head      struct {
+		SHA string `json:"sha"`
+	} `json:"head"`
+}
+
+func (pr *giteaPullRequest) toInfo() pullRequestInfo {
+	info := pullRequestInfo{
+		Number:    strconv.FormatInt(pr.Number, 10),
+		Title:     pr.Title,
+		Author:    pr.User.Login,
+		Draft:     pr.Draft,
+		HeadSHA:   pr.Head.SHA,
+		Closed:    isClosedState(pr.State),
+		UpdatedAt: pr.UpdatedAt,
+	}
+	if pr.MergedBy != nil {
+		info.MergedBy = pr.MergedBy.Login
+	}
+	for i := range pr.Labels {
+		info.Labels = append(info.Labels, pr.Labels[i].Name)
+	}
+
+	return info
+}
+
+// giteaTimelineComment is a comment of the timeline of an issue or a pull request.
+type giteaTimelineComment struct {
+	Type      string      `json:"type"`
+	Body      string      `json:"body"`
+	User      *githubUser `json:"user"`
+	Label     *giteaLabel `json:"label"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// giteaClient implements iClient by the REST APIs of Gitea, which are the same as the ones of Forgejo.
+type giteaClient struct {
+	genericEvents
+	rest   *restClient
+	logger *logrus.Entry
+}
+
+func newGiteaClient(token []byte, apiURL string, logger *logrus.Entry) *giteaClient {
+	auth := "token " + strings.TrimSpace(string(token))
+	rest := newRESTClient(apiURL, func(req *http.Request) {
+		req.Header.Set("Authorization", auth)
+	}, logger)
+	rest.limitParam, rest.pageSize = "limit", giteaPageSize
+
+	return &giteaClient{rest: rest, logger: logger}
+}
+
+func (c *giteaClient) repoPath(org, repo string, elem ...string) string {
+	return "repos/" + url.PathEscape(org) + "/" + url.PathEscape(repo) + "/" + strings.Join(elem, "/")
+}
+
+// labelIDs returns the ids of the labels, the ones which don't exist in the repository are created.
+func (c *giteaClient) labelIDs(org, repo string, labels []string) ([]int64, bool) {
+	existing, ok := listAll[giteaLabel](c.rest, c.repoPath(org, repo, "labels"), nil)
+	if !ok {
+		return nil, false
+	}
+	m := make(map[string]int64, len(existing))
+	for i := range existing {
+		m[existing[i].Name] = existing[i].ID
+	}
+
+	ids := make([]int64, 0, len(labels))
+	for _, l := range labels {
+		if id, ok := m[l]; ok {
+			ids = append(ids, id)
+			continue
+		}
+
+		var created giteaLabel
+		if !c.rest.do(http.MethodPost, c.repoPath(org, repo, "labels"), nil,
+			map[string]string{"name": l, "color": giteaLabelColor}, &created) {
+			return nil, false
+		}
+		ids = append(ids, created.ID)
+	}
+
+	return ids, true
+}
+
+// changeAssignees replaces the assignees of the pull request with the changed ones.
+func (c *giteaClient) changeAssignees(org, repo, number string, logins []string, add bool) bool {
+	if len(logins) == 0 {
+		return false
+	}
+
+	var issue struct {
+		Assignees []githubUser `json:"assignees"`
+	}
+	if !c.rest.do(http.MethodGet, c.repoPath(org, repo, "issues", number), nil, nil, &issue) {
+		return false
+	}
+
+	v := sets.New[string]()
+	for i := range issue.Assignees {
+		v.Insert(issue.Assignees[i].Login)
+	}
+	if add {
+		v.Insert(logins...)
+	} else {
+		v.Delete(logins...)
+	}
+
+	return c.rest.do(http.MethodPatch, c.repoPath(org, repo, "issues", number), nil,
+		map[string][]string{"assignees": sets.List(v)}, nil)
+}
+
+func (c *giteaClient) CreatePRComment(org, repo, number, comment string) (success bool) {
+	return c.rest.do(http.MethodPost, c.repoPath(org, repo, "issues", number, "comments"), nil,
+		map[string]string{"body": comment}, nil)
+}
+
+func (c *giteaClient) AddPRLabels(org, repo, number string, labels []string) (success bool) {
+	if len(labels) == 0 {
+		return
+	}
+
+	ids, ok := c.labelIDs(org, repo, labels)
+	if !ok {
+		return false
+	}
+
+	return c.rest.do(http.MethodPost, c.repoPath(org, repo, "issues", number, "labels"), nil,
+		map[string][]int64{"labels": ids}, nil)
+}
+
+func (c *giteaClient) RemovePRLabels(org, repo, number string, labels []string) (success bool) {
+	if len(labels) == 0 {
+		return
+	}
+
+	var current []giteaLabel
+	if !c.rest.do(http.MethodGet, c.repoPath(org, repo, "issues", number, "labels"), nil, nil, &current) {
+		return false
+	}
+
+	v := sets.New[string](labels...)
+	success = true
+	for i := range current {
+		if !v.Has(current[i].Name) {
+			continue
+		}
+		id := strconv.FormatInt(current[i].ID, 10)
+		if !c.rest.do(http.MethodDelete, c.repoPath(org, repo, "issues", number, "labels", id), nil, nil, nil) {
+			success = false
+		}
+	}
+	return
+}
+
+func (c *giteaClient) GetPullRequestCommits(org, repo, number string) (result []client.PRCommit, success bool) {
+	commits, success := listAll[githubCommit](c.rest, c.repoPath(org, repo, "pulls", number, "commits"), nil)
+	for i := range commits {
+		v := &commits[i].Commit
+		result = append(result, client.PRCommit{
+			AuthorName:     v.Author.Name,
+			AuthorEmail:    v.Author.Email,
+			CommitterName:  v.Committer.Name,
+			CommitterEmail: v.Committer.Email,
+		})
+	}
+	return
+}
+
+// ListPullRequestComments lists all the comments, Gitea doesn't paginate them.
+func (c *giteaClient) ListPullRequestComments(org, repo, number string) (result []client.PRComment, success bool) {
+	var comments []githubComment
+	if !c.rest.do(http.MethodGet, c.repoPath(org, repo, "issues", number, "comments"), nil, nil, &comments) {
+		return nil, false
+	}
+
+	for i := range comments {
+		result = append(result, client.PRComment{
+			ID:   strconv.FormatInt(comments[i].ID, 10),
+			Body: comments[i].Body,
+		})
+	}
+	return result, true
+}
+
+func (c *giteaClient) DeletePRComment(org, repo, commentID string) (success bool) {
+	return c.rest.do(http.MethodDelete, c.repoPath(org, repo, "issues", "comments", commentID), nil, nil, nil)
+}
+
+func (c *giteaClient) CheckCLASignature(urlStr string) (signState string, success bool) {
+	return checkCLASignature(urlStr, c.logger)
+}
+
+// CheckPermission checks whether the user can write the repository.
+func (c *giteaClient) CheckPermission(org, repo, username string) (pass, success bool) {
+	var v struct {
+		Permission string `json:"permission"`
+	}
+	path := c.repoPath(org, repo, "collaborators", url.PathEscape(username), "permission")
+	if err := c.rest.send(http.MethodGet, path, nil, nil, &v); err != nil {
+		if isNotFound(err) {
+			return false, true
+		}
+		c.logger.WithError(err).Errorf("failed to get the permission of %s", username)
+		return false, false
+	}
+
+	return v.Permission == "owner" || v.Permission == "admin" || v.Permission == "write", true
+}
+
+func (c *giteaClient) GetPullRequestLabels(org, repo, number string) (result []string, success bool) {
+	var labels []giteaLabel
+	if !c.rest.do(http.MethodGet, c.repoPath(org, repo, "issues", number, "labels"), nil, nil, &labels) {
+		return nil, false
+	}
+
+	for i := range labels {
+		result = append(result, labels[i].Name)
+	}
+	return result, true
+}
+
+// MergePullRequest merges the pull request, the merge methods of the robot are accepted by Gitea as they are.
+func (c *giteaClient) MergePullRequest(org, repo, number, mergeMethod string) (success bool) {
+	return c.rest.do(http.MethodPost, c.repoPath(org, repo, "pulls", number, "merge"), nil,
+		map[string]string{"Do": mergeMethod}, nil)
+}
+
+// ListPullRequestOperationLogs builds the logs of adding and removing labels by the timeline of the pull request,
+// because Gitea doesn't provide the operation logs.
+func (c *giteaClient) ListPullRequestOperationLogs(org, repo, number string) (
+	result []client.PullRequestOperationLog, success bool,
+) {
+	comments, success := listAll[giteaTimelineComment](c.rest, c.repoPath(org, repo, "issues", number, "timeline"), nil)
+	for i := range comments {
+		e := &comments[i]
+		if e.Type != giteaTimelineLabel || e.Label == nil || e.User == nil {
+			continue
+		}
+
+		action := ActionRemoveLabel
+		if e.Body == giteaLabelAdded {
+			action = ActionAddLabel
+		}
+
+		result = append(result, client.PullRequestOperationLog{
+			CreatedAt: e.CreatedAt,
+			UpdatedAt: e.CreatedAt,
+			Content:   action + " " + e.Label.Name,
+			Action:    e.Type,
+			UserName:  e.User.Login,
+		})
+	}
+	return
+}
+
+// GetPullRequestChanges splits the diff of the pull request by the files, because the changed files
+// listed by Gitea don't carry the patches.
+func (c *giteaClient) GetPullRequestChanges(org, repo, number string) (result []client.CommitFile, success bool) {
+	var diff []byte
+	if !c.rest.do(http.MethodGet, c.repoPath(org, repo, "pulls", number+".diff"), nil, nil, &diff) {
+		return nil, false
+	}
+
+	return splitGitDiff(diff), true
+}
+
+func (c *giteaClient) AssignPullRequest(org, repo, number string, logins []string) (success bool) {
+	return c.changeAssignees(org, repo, number, logins, true)
+}
+
+func (c *giteaClient) UnassignPullRequest(org, repo, number string, logins []string) (success bool) {
+	return c.changeAssignees(org, repo, number, logins, false)
+}
+
+func (c *giteaClient) RequestPullRequestReviewers(org, repo, number string, logins []string) (success bool) {
+	if len(logins) == 0 {
+		return
+	}
+
+	return c.rest.do(http.MethodPost, c.repoPath(org, repo, "pulls", number, "requested_reviewers"), nil,
+		map[string][]string{"reviewers": logins}, nil)
+}
+
+func (c *giteaClient) AddCommentReaction(org, repo, number, commentID, reaction string) (success bool) {
+	return c.rest.do(http.MethodPost, c.repoPath(org, repo, "issues", "comments", commentID, "reactions"), nil,
+		map[string]string{"content": reaction}, nil)
+}
+
+func (c *giteaClient) GetCurrentUser() (login string, success bool) {
+	var v githubUser
+	if !c.rest.do(http.MethodGet, "user", nil, nil, &v) {
+		return "", false
+	}
+
+	return v.Login, true
+}
+
+func (c *giteaClient) ClosePullRequest(org, repo, number string) (success bool) {
+	return c.rest.do(http.MethodPatch, c.repoPath(org, repo, "pulls", number), nil,
+		map[string]string{"state": "closed"}, nil)
+}
+
+func (c *giteaClient) ReopenPullRequest(org, repo, number string) (success bool) {
+	return c.rest.do(http.MethodPatch, c.repoPath(org, repo, "pulls", number), nil,
+		map[string]string{"state": "open"}, nil)
+}
+
+func (c *giteaClient) GetPullRequestInfo(org, repo, number string) (result pullRequestInfo, success bool) {
+	var pr giteaPullRequest
+	if !c.rest.do(http.MethodGet, c.repoPath(org, repo, "pulls", number), nil, nil, &pr) {
+		return result, false
+	}
+
+	return pr.toInfo(), true
+}
+
+func (c *giteaClient) UpdatePullRequestTitle(org, repo, number, title string) (success bool) {
+	return c.rest.do(http.MethodPatch, c.repoPath(org, repo, "pulls", number), nil,
+		map[string]string{"title": title}, nil)
+}
+
+func (c *giteaClient) ListOpenPullRequests(org, repo string) (result []pullRequestInfo, success bool) {
+	prs, success := listAll[giteaPullRequest](c.rest, c.repoPath(org, repo, "pulls"),
+		url.Values{"state": []string{"open"}})
+	for i := range prs {
+		result = append(result, prs[i].toInfo())
+	}
+	return
+}
+
+// splitGitDiff splits the output of 'git diff' into the files. The patch of a file only contains the hunks,
+// and it is regarded as too large if the file is binary.
+func splitGitDiff(diff []byte) []client.CommitFile {
+	var files []client.CommitFile
+	var hunks strings.Builder
+	var oldPath, newPath, status string
+	inHunks, binary := false, false
+
+	flush := func() {
+		if newPath == "" {
+			return
+		}
+
+		tooLarge := binary
+		files = append(files, client.CommitFile{
+			Filename:         newString(newPath),
+			Status:           newString(status),
+			PreviousFilename: newString(oldPath),
+			Patch: &client.CommitPatch{
+				Diff:     newString(hunks.String()),
+				OldPath:  newString(oldPath),
+				NewPath:  newString(newPath),
+				TooLarge: &tooLarge,
+			},
+		})
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+			hunks.Reset()
+			inHunks, binary, status = false, false, "modified"
+			oldPath, newPath = parseDiffHeader(strings.TrimPrefix(line, "diff --git "))
+			continue
+		}
+
+		if inHunks {
+			hunks.WriteString(line)
+			hunks.WriteByte('\n')
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			inHunks = true
+			hunks.WriteString(line)
+			hunks.WriteByte('\n')
+		case strings.HasPrefix(line, "new file mode"):
+			status = "added"
+		case strings.HasPrefix(line, "deleted file mode"):
+			status = "removed"
+		case strings.HasPrefix(line, "rename from "):
+			status, oldPath = "renamed", strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to "):
+			newPath = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "Binary files "), strings.HasPrefix(line, "GIT binary patch"):
+			binary = true
+		}
+	}
+	flush()
+
+	return files
+}
+
+// parseDiffHeader parses 'a/<old path> b/<new path>'. The paths are the same if the file is not renamed,
+// which is used to split them if they contain spaces.
+func parseDiffHeader(s string) (oldPath, newPath string) {
+	s = strings.TrimPrefix(s, "a/")
+	if n := len(s); n%2 == 1 {
+		if half := (n - 3) / 2; s[half:half+3] == " b/" && s[:half] == s[half+3:] {
+			return s[:half], s[half+3:]
+		}
+	}
+
+	if i := strings.Index(s, " b/"); i >= 0 {
+		return s[:i], s[i+3:]
+	}
+
+	return s, s
+}
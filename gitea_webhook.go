@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/opensourceways/robot-framework-lib/framework"
+)
+
+// Forgejo sends both the headers of its own and the ones of Gitea, the latter are used.
+const (
+	giteaHeaderEvent     = "X-Gitea-Event"
+	giteaHeaderDelivery  = "X-Gitea-Delivery"
+	giteaHeaderSignature = "X-Gitea-Signature"
+
+	giteaEventPullRequest         = "pull_request"
+	giteaEventPullRequestApproved = "pull_request_approved"
+	giteaEventPullRequestRejected = "pull_request_rejected"
+	giteaEventIssueComment        = "issue_comment"
+)
+
+type giteaPullRequestReviewEvent struct {
+	githubPullRequestEvent
+
+	Review struct {
+		Content string `json:"content"`
+	} `json:"review"`
+}
+
+// giteaWebhook translates the webhooks of Gitea and Forgejo, whose payloads are similar to the ones of GitHub.
+type giteaWebhook struct{}
+
+func (giteaWebhook) verify(r *http.Request, payload, secret []byte) bool {
+	want, err := hex.DecodeString(r.Header.Get(giteaHeaderSignature))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(payload)
+	return hmac.Equal(mac.Sum(nil), want)
+}
+
+func (w giteaWebhook) translate(r *http.Request, payload []byte) (*client.GenericEvent, error) {
+	var evt *client.GenericEvent
+	var err error
+
+	switch r.Header.Get(giteaHeaderEvent) {
+	case giteaEventPullRequest:
+		evt, err = w.translatePullRequest(payload)
+	case giteaEventPullRequestApproved:
+		evt, err = w.translatePullRequestReview(payload, reviewStateApproved)
+	case giteaEventPullRequestRejected:
+		evt, err = w.translatePullRequestReview(payload, reviewStateChangesRequested)
+	case giteaEventIssueComment:
+		// the comments on the pull requests are the same as the ones of GitHub
+		evt, err = githubWebhook{}.translateIssueComment(payload)
+	}
+	if evt != nil {
+		evt.EventGUID = newString(r.Header.Get(giteaHeaderDelivery))
+	}
+
+	return evt, err
+}
+
+func (giteaWebhook) translatePullRequest(payload []byte) (*client.GenericEvent, error) {
+	var e githubPullRequestEvent
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, err
+	}
+
+	state, action, detail := eventStateOpened, eventActionUpdate, ""
+	switch e.Action {
+	case "opened":
+		action = eventActionOpen
+	case "reopened":
+		action = eventActionReopen
+	case "synchronized":
+		detail = eventDetailSourceUpdate
+	case "label_updated", "label_cleared":
+		detail = eventDetailUpdateLabel
+	case "closed":
+		state, action = eventStateClosed, eventActionClose
+		if e.PullRequest.Merged {
+			state, action = eventStateMerged, eventActionMerge
+		}
+	case "edited":
+	default:
+		return nil, nil
+	}
+
+	pr := &e.PullRequest
+	evt := &client.GenericEvent{}
+	evt.EventType = newString(framework.PullRequestEvent)
+	evt.State = newString(state)
+	evt.Action = newString(action)
+	evt.ActionDetail = newString(detail)
+	evt.Org = newString(e.Repository.Owner.Login)
+	evt.Repo = newString(e.Repository.Name)
+	evt.HtmlURL = newString(pr.HTMLURL)
+	evt.Base = newString(pr.Base.Ref)
+	evt.Head = newString(pr.Head.Ref)
+	evt.Number = newString(strconv.FormatInt(pr.Number, 10))
+	evt.Author = newString(pr.User.Login)
+
+	return evt, nil
+}
+
+// translatePullRequestReview translates the submitted reviews. The payload doesn't carry the id of the review,
+// and the dismissed reviews are not sent, so the reviews of a reviewer are identified by the reviewer.
+func (giteaWebhook) translatePullRequestReview(payload []byte, reviewState string) (*client.GenericEvent, error) {
+	var e giteaPullRequestReviewEvent
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, err
+	}
+
+	state := eventStateOpened
+	if e.PullRequest.State == "closed" {
+		state = eventStateClosed
+	}
+
+	pr := &e.PullRequest
+	evt := &client.GenericEvent{}
+	evt.EventType = newString(framework.PullRequestEvent)
+	evt.State = newString(state)
+	evt.Action = newString(eventActionReview)
+	evt.ActionDetail = newString(reviewState)
+	evt.Org = newString(e.Repository.Owner.Login)
+	evt.Repo = newString(e.Repository.Name)
+	evt.HtmlURL = newString(pr.HTMLURL)
+	evt.Base = newString(pr.Base.Ref)
+	evt.Head = newString(pr.Head.Ref)
+	evt.Number = newString(strconv.FormatInt(pr.Number, 10))
+	evt.Author = newString(pr.User.Login)
+	evt.CommentID = newString("review/" + e.Sender.Login)
+	evt.Comment = newString(e.Review.Content)
+	evt.Commenter = newString(e.Sender.Login)
+
+	return evt, nil
+}
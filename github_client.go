@@ -0,0 +1,346 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/sirupsen/logrus"
+)
+
+const githubAPIBaseURL = "https://api.github.com/"
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubLabel struct {
+	Name string `json:"name"`
+}
+
+type githubPullRequest struct {
+	Number    int64         `json:"number"`
+	Title     string        `json:"title"`
+	State     string        `json:"state"`
+	Draft     bool          `json:"draft"`
+	Merged    bool          `json:"merged"`
+	HTMLURL   string        `json:"html_url"`
+	User      githubUser    `json:"user"`
+	MergedBy  *githubUser   `json:"merged_by"`
+	Labels    []githubLabel `json:"labels"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	Head      struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+func (pr *githubPullRequest) toInfo() pullRequestInfo {
+	info := pullRequestInfo{
+		Number:    strconv.FormatInt(pr.Number, 10),
+		Title:     pr.Title,
+		Author:    pr.User.Login,
+		Draft:     pr.Draft,
+		HeadSHA:   pr.Head.SHA,
+		Closed:    isClosedState(pr.State),
+		UpdatedAt: pr.UpdatedAt,
+	}
+	if pr.MergedBy != nil {
+		info.MergedBy = pr.MergedBy.Login
+	}
+	for i := range pr.Labels {
+		info.Labels = append(info.Labels, pr.Labels[i].Name)
+	}
+
+	return info
+}
+
+type githubComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+type githubCommit struct {
+	Commit struct {
+		Author struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		} `json:"author"`
+		Committer struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		} `json:"committer"`
+	} `json:"commit"`
+}
+
+type githubFile struct {
+	SHA              string `json:"sha"`
+	Filename         string `json:"filename"`
+	Status           string `json:"status"`
+	Additions        int    `json:"additions"`
+	Deletions        int    `json:"deletions"`
+	Changes          int    `json:"changes"`
+	Patch            string `json:"patch"`
+	PreviousFilename string `json:"previous_filename"`
+}
+
+// githubTimelineEvent is an event of the timeline of an issue or a pull request.
+type githubTimelineEvent struct {
+	Event     string       `json:"event"`
+	Actor     *githubUser  `json:"actor"`
+	Label     *githubLabel `json:"label"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// githubClient implements iClient by the REST APIs of GitHub.
+type githubClient struct {
+	genericEvents
+	rest   *restClient
+	logger *logrus.Entry
+}
+
+func newGitHubClient(token []byte, apiURL string, logger *logrus.Entry) *githubClient {
+	auth := "Bearer " + strings.TrimSpace(string(token))
+	return &githubClient{
+		rest: newRESTClient(apiURL, func(req *http.Request) {
+			req.Header.Set("Authorization", auth)
+			req.Header.Set("Accept", "application/vnd.github+json")
+		}, logger),
+		logger: logger,
+	}
+}
+
+func (c *githubClient) repoPath(org, repo string, elem ...string) string {
+	return "repos/" + url.PathEscape(org) + "/" + url.PathEscape(repo) + "/" + strings.Join(elem, "/")
+}
+
+func (c *githubClient) CreatePRComment(org, repo, number, comment string) (success bool) {
+	return c.rest.do(http.MethodPost, c.repoPath(org, repo, "issues", number, "comments"), nil,
+		map[string]string{"body": comment}, nil)
+}
+
+func (c *githubClient) AddPRLabels(org, repo, number string, labels []string) (success bool) {
+	if len(labels) == 0 {
+		return
+	}
+
+	return c.rest.do(http.MethodPost, c.repoPath(org, repo, "issues", number, "labels"), nil,
+		map[string][]string{"labels": labels}, nil)
+}
+
+func (c *githubClient) RemovePRLabels(org, repo, number string, labels []string) (success bool) {
+	if len(labels) == 0 {
+		return
+	}
+
+	success = true
+	for _, l := range labels {
+		if !c.rest.do(http.MethodDelete, c.repoPath(org, repo, "issues", number, "labels", url.PathEscape(l)),
+			nil, nil, nil) {
+			success = false
+		}
+	}
+	return
+}
+
+func (c *githubClient) GetPullRequestCommits(org, repo, number string) (result []client.PRCommit, success bool) {
+	commits, success := listAll[githubCommit](c.rest, c.repoPath(org, repo, "pulls", number, "commits"), nil)
+	for i := range commits {
+		v := &commits[i].Commit
+		result = append(result, client.PRCommit{
+			AuthorName:     v.Author.Name,
+			AuthorEmail:    v.Author.Email,
+			CommitterName:  v.Committer.Name,
+			CommitterEmail: v.Committer.Email,
+		})
+	}
+	return
+}
+
+func (c *githubClient) ListPullRequestComments(org, repo, number string) (result []client.PRComment, success bool) {
+	comments, success := listAll[githubComment](c.rest, c.repoPath(org, repo, "issues", number, "comments"), nil)
+	for i := range comments {
+		result = append(result, client.PRComment{
+			ID:   strconv.FormatInt(comments[i].ID, 10),
+			Body: comments[i].Body,
+		})
+	}
+	return
+}
+
+func (c *githubClient) DeletePRComment(org, repo, commentID string) (success bool) {
+	return c.rest.do(http.MethodDelete, c.repoPath(org, repo, "issues", "comments", commentID), nil, nil, nil)
+}
+
+func (c *githubClient) CheckCLASignature(urlStr string) (signState string, success bool) {
+	return checkCLASignature(urlStr, c.logger)
+}
+
+// CheckPermission checks whether the user can write the repository.
+func (c *githubClient) CheckPermission(org, repo, username string) (pass, success bool) {
+	var v struct {
+		Permission string `json:"permission"`
+	}
+	if !c.rest.do(http.MethodGet, c.repoPath(org, repo, "collaborators", url.PathEscape(username), "permission"),
+		nil, nil, &v) {
+		return false, false
+	}
+
+	return v.Permission == "admin" || v.Permission == "write", true
+}
+
+func (c *githubClient) GetPullRequestLabels(org, repo, number string) (result []string, success bool) {
+	labels, success := listAll[githubLabel](c.rest, c.repoPath(org, repo, "issues", number, "labels"), nil)
+	for i := range labels {
+		result = append(result, labels[i].Name)
+	}
+	return
+}
+
+func (c *githubClient) MergePullRequest(org, repo, number, mergeMethod string) (success bool) {
+	return c.rest.do(http.MethodPut, c.repoPath(org, repo, "pulls", number, "merge"), nil,
+		map[string]string{"merge_method": mergeMethod}, nil)
+}
+
+// ListPullRequestOperationLogs builds the logs of adding and removing labels by the timeline of the pull request,
+// because GitHub doesn't provide the operation logs.
+func (c *githubClient) ListPullRequestOperationLogs(org, repo, number string) (
+	result []client.PullRequestOperationLog, success bool,
+) {
+	events, success := listAll[githubTimelineEvent](c.rest, c.repoPath(org, repo, "issues", number, "timeline"), nil)
+	for i := range events {
+		e := &events[i]
+		if e.Label == nil || e.Actor == nil {
+			continue
+		}
+
+		var action string
+		switch e.Event {
+		case "labeled":
+			action = ActionAddLabel
+		case "unlabeled":
+			action = ActionRemoveLabel
+		default:
+			continue
+		}
+
+		result = append(result, client.PullRequestOperationLog{
+			CreatedAt: e.CreatedAt,
+			UpdatedAt: e.CreatedAt,
+			Content:   action + " " + e.Label.Name,
+			Action:    e.Event,
+			UserName:  e.Actor.Login,
+		})
+	}
+	return
+}
+
+func (c *githubClient) GetPullRequestChanges(org, repo, number string) (result []client.CommitFile, success bool) {
+	files, success := listAll[githubFile](c.rest, c.repoPath(org, repo, "pulls", number, "files"), nil)
+	for i := range files {
+		f := &files[i]
+		oldPath := f.Filename
+		if f.PreviousFilename != "" {
+			oldPath = f.PreviousFilename
+		}
+
+		// the patch is missing if the file is binary or too large
+		tooLarge := f.Patch == "" && f.Changes > 0
+		result = append(result, client.CommitFile{
+			SHA:              newString(f.SHA),
+			Filename:         newString(f.Filename),
+			Additions:        &f.Additions,
+			Deletions:        &f.Deletions,
+			Changes:          &f.Changes,
+			Status:           newString(f.Status),
+			PreviousFilename: newString(f.PreviousFilename),
+			Patch: &client.CommitPatch{
+				Diff:     newString(f.Patch),
+				OldPath:  newString(oldPath),
+				NewPath:  newString(f.Filename),
+				TooLarge: &tooLarge,
+			},
+		})
+	}
+	return
+}
+
+func (c *githubClient) AssignPullRequest(org, repo, number string, logins []string) (success bool) {
+	if len(logins) == 0 {
+		return
+	}
+
+	return c.rest.do(http.MethodPost, c.repoPath(org, repo, "issues", number, "assignees"), nil,
+		map[string][]string{"assignees": logins}, nil)
+}
+
+func (c *githubClient) UnassignPullRequest(org, repo, number string, logins []string) (success bool) {
+	if len(logins) == 0 {
+		return
+	}
+
+	return c.rest.do(http.MethodDelete, c.repoPath(org, repo, "issues", number, "assignees"), nil,
+		map[string][]string{"assignees": logins}, nil)
+}
+
+func (c *githubClient) RequestPullRequestReviewers(org, repo, number string, logins []string) (success bool) {
+	if len(logins) == 0 {
+		return
+	}
+
+	return c.rest.do(http.MethodPost, c.repoPath(org, repo, "pulls", number, "requested_reviewers"), nil,
+		map[string][]string{"reviewers": logins}, nil)
+}
+
+func (c *githubClient) AddCommentReaction(org, repo, number, commentID, reaction string) (success bool) {
+	return c.rest.do(http.MethodPost, c.repoPath(org, repo, "issues", "comments", commentID, "reactions"), nil,
+		map[string]string{"content": reaction}, nil)
+}
+
+func (c *githubClient) GetCurrentUser() (login string, success bool) {
+	var v githubUser
+	if !c.rest.do(http.MethodGet, "user", nil, nil, &v) {
+		return "", false
+	}
+
+	return v.Login, true
+}
+
+func (c *githubClient) ClosePullRequest(org, repo, number string) (success bool) {
+	return c.rest.do(http.MethodPatch, c.repoPath(org, repo, "pulls", number), nil,
+		map[string]string{"state": "closed"}, nil)
+}
+
+func (c *githubClient) ReopenPullRequest(org, repo, number string) (success bool) {
+	return c.rest.do(http.MethodPatch, c.repoPath(org, repo, "pulls", number), nil,
+		map[string]string{"state": "open"}, nil)
+}
+
+func (c *githubClient) GetPullRequestInfo(org, repo, number string) (result pullRequestInfo, success bool) {
+	var pr githubPullRequest
+	if !c.rest.do(http.MethodGet, c.repoPath(org, repo, "pulls", number), nil, nil, &pr) {
+		return result, false
+	}
+
+	return pr.toInfo(), true
+}
+
+func (c *githubClient) UpdatePullRequestTitle(org, repo, number, title string) (success bool) {
+	return c.rest.do(http.MethodPatch, c.repoPath(org, repo, "pulls", number), nil,
+		map[string]string{"title": title}, nil)
+}
+
+func (c *githubClient) ListOpenPullRequests(org, repo string) (result []pullRequestInfo, success bool) {
+	prs, success := listAll[githubPullRequest](c.rest, c.repoPath(org, repo, "pulls"),
+		url.Values{"state": []string{"open"}})
+	for i := range prs {
+		result = append(result, prs[i].toInfo())
+	}
+	return
+}
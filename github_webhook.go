@@ -0,0 +1,240 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/opensourceways/robot-framework-lib/framework"
+)
+
+const (
+	githubHeaderEvent     = "X-GitHub-Event"
+	githubHeaderDelivery  = "X-GitHub-Delivery"
+	githubHeaderSignature = "X-Hub-Signature-256"
+	githubSignaturePrefix = "sha256="
+
+	githubEventPullRequest       = "pull_request"
+	githubEventPullRequestReview = "pull_request_review"
+	githubEventIssueComment      = "issue_comment"
+)
+
+type githubRepository struct {
+	Name  string     `json:"name"`
+	Owner githubUser `json:"owner"`
+}
+
+type githubPullRequestEvent struct {
+	Action      string            `json:"action"`
+	PullRequest githubPullRequest `json:"pull_request"`
+	Repository  githubRepository  `json:"repository"`
+	Sender      githubUser        `json:"sender"`
+}
+
+type githubPullRequestReviewEvent struct {
+	Action string `json:"action"`
+	Review struct {
+		ID    int64      `json:"id"`
+		Body  string     `json:"body"`
+		State string     `json:"state"`
+		User  githubUser `json:"user"`
+	} `json:"review"`
+	PullRequest githubPullRequest `json:"pull_request"`
+	Repository  githubRepository  `json:"repository"`
+	Sender      githubUser        `json:"sender"`
+}
+
+type githubIssueCommentEvent struct {
+	Action string `json:"action"`
+	Issue  struct {
+		Number      int64      `json:"number"`
+		State       string     `json:"state"`
+		HTMLURL     string     `json:"html_url"`
+		User        githubUser `json:"user"`
+		PullRequest *struct {
+			URL string `json:"url"`
+		} `json:"pull_request"`
+	} `json:"issue"`
+	Comment struct {
+		ID        int64      `json:"id"`
+		Body      string     `json:"body"`
+		User      githubUser `json:"user"`
+		CreatedAt string     `json:"created_at"`
+		UpdatedAt string     `json:"updated_at"`
+	} `json:"comment"`
+	Repository githubRepository `json:"repository"`
+}
+
+// githubWebhook translates the webhooks of GitHub.
+type githubWebhook struct{}
+
+func (githubWebhook) verify(r *http.Request, payload, secret []byte) bool {
+	sig := strings.TrimPrefix(r.Header.Get(githubHeaderSignature), githubSignaturePrefix)
+	want, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(payload)
+	return hmac.Equal(mac.Sum(nil), want)
+}
+
+func (w githubWebhook) translate(r *http.Request, payload []byte) (*client.GenericEvent, error) {
+	var evt *client.GenericEvent
+	var err error
+
+	switch r.Header.Get(githubHeaderEvent) {
+	case githubEventPullRequest:
+		evt, err = w.translatePullRequest(payload)
+	case githubEventPullRequestReview:
+		evt, err = w.translatePullRequestReview(payload)
+	case githubEventIssueComment:
+		evt, err = w.translateIssueComment(payload)
+	}
+	if evt != nil {
+		evt.EventGUID = newString(r.Header.Get(githubHeaderDelivery))
+	}
+
+	return evt, err
+}
+
+func (githubWebhook) translatePullRequest(payload []byte) (*client.GenericEvent, error) {
+	var e githubPullRequestEvent
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, err
+	}
+
+	state, action, detail := eventStateOpened, eventActionUpdate, ""
+	switch e.Action {
+	case "opened":
+		action = eventActionOpen
+	case "reopened":
+		action = eventActionReopen
+	case "synchronize":
+		detail = eventDetailSourceUpdate
+	case "labeled", "unlabeled":
+		detail = eventDetailUpdateLabel
+	case "closed":
+		state, action = eventStateClosed, eventActionClose
+		if e.PullRequest.Merged {
+			state, action = eventStateMerged, eventActionMerge
+		}
+	case "edited", "ready_for_review", "converted_to_draft":
+	default:
+		return nil, nil
+	}
+
+	pr := &e.PullRequest
+	evt := &client.GenericEvent{}
+	evt.EventType = newString(framework.PullRequestEvent)
+	evt.State = newString(state)
+	evt.Action = newString(action)
+	evt.ActionDetail = newString(detail)
+	evt.Org = newString(e.Repository.Owner.Login)
+	evt.Repo = newString(e.Repository.Name)
+	evt.HtmlURL = newString(pr.HTMLURL)
+	evt.Base = newString(pr.Base.Ref)
+	evt.Head = newString(pr.Head.Ref)
+	evt.Number = newString(strconv.FormatInt(pr.Number, 10))
+	evt.Author = newString(pr.User.Login)
+
+	return evt, nil
+}
+
+// translatePullRequestReview translates the submitted and dismissed reviews, the ones only commenting are ignored.
+func (githubWebhook) translatePullRequestReview(payload []byte) (*client.GenericEvent, error) {
+	var e githubPullRequestReviewEvent
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, err
+	}
+
+	var reviewState string
+	user := e.Review.User.Login
+	switch {
+	case e.Action == "dismissed":
+		reviewState, user = reviewStateDismissed, e.Sender.Login
+	case e.Action != "submitted":
+		return nil, nil
+	case e.Review.State == "approved":
+		reviewState = reviewStateApproved
+	case e.Review.State == "changes_requested":
+		reviewState = reviewStateChangesRequested
+	default:
+		return nil, nil
+	}
+
+	state := eventStateOpened
+	if e.PullRequest.State == "closed" {
+		state = eventStateClosed
+	}
+
+	pr := &e.PullRequest
+	evt := &client.GenericEvent{}
+	evt.EventType = newString(framework.PullRequestEvent)
+	evt.State = newString(state)
+	evt.Action = newString(eventActionReview)
+	evt.ActionDetail = newString(reviewState)
+	evt.Org = newString(e.Repository.Owner.Login)
+	evt.Repo = newString(e.Repository.Name)
+	evt.HtmlURL = newString(pr.HTMLURL)
+	evt.Base = newString(pr.Base.Ref)
+	evt.Head = newString(pr.Head.Ref)
+	evt.Number = newString(strconv.FormatInt(pr.Number, 10))
+	evt.Author = newString(pr.User.Login)
+	evt.CommentID = newString(strconv.FormatInt(e.Review.ID, 10))
+	evt.Comment = newString(e.Review.Body)
+	evt.Commenter = newString(user)
+
+	return evt, nil
+}
+
+func (githubWebhook) translateIssueComment(payload []byte) (*client.GenericEvent, error) {
+	var e githubIssueCommentEvent
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, err
+	}
+	if e.Issue.PullRequest == nil {
+		// the comments on the issues are not handled
+		return nil, nil
+	}
+
+	var noteAction string
+	switch e.Action {
+	case "created":
+	case "edited":
+		noteAction = noteActionUpdate
+	case "deleted":
+		noteAction = noteActionDelete
+	default:
+		return nil, nil
+	}
+
+	state := eventStateOpened
+	if e.Issue.State == "closed" {
+		state = eventStateClosed
+	}
+
+	evt := &client.GenericEvent{}
+	evt.EventType = newString(framework.NoteEvent)
+	evt.State = newString(state)
+	evt.ActionDetail = newString(noteAction)
+	evt.Org = newString(e.Repository.Owner.Login)
+	evt.Repo = newString(e.Repository.Name)
+	evt.HtmlURL = newString(e.Issue.HTMLURL)
+	evt.Number = newString(strconv.FormatInt(e.Issue.Number, 10))
+	evt.Author = newString(e.Issue.User.Login)
+	evt.CommentID = newString(strconv.FormatInt(e.Comment.ID, 10))
+	evt.CommentKind = newString(client.CommentOnPR)
+	evt.Comment = newString(e.Comment.Body)
+	evt.Commenter = newString(e.Comment.User.Login)
+	evt.CreateTime = newString(e.Comment.CreatedAt)
+	evt.UpdateTime = newString(e.Comment.UpdatedAt)
+
+	return evt, nil
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	gitlabAPIBaseURL = "https://gitlab.com/api/v4/"
+
+	// gitlabDeveloperAccess is the lowest access level which can push to the repository
+	gitlabDeveloperAccess = 30
+)
+
+// gitlabEmoji maps the reactions of GitHub to the emoji names of GitLab.
+var gitlabEmoji = map[string]string{
+	"+1": "thumbsup",
+	"-1": "thumbsdown",
+}
+
+type gitlabUser struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+type gitlabMergeRequest struct {
+	IID       int64        `json:"iid"`
+	Title     string       `json:"title"`
+	State     string       `json:"state"`
+	Draft     bool         `json:"draft"`
+	SHA       string       `json:"sha"`
+	Author    gitlabUser   `json:"author"`
+	MergedBy  *gitlabUser  `json:"merged_by"`
+	Labels    []string     `json:"labels"`
+	Assignees []gitlabUser `json:"assignees"`
+	Reviewers []gitlabUser `json:"reviewers"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+func (mr *gitlabMergeRequest) toInfo() pullRequestInfo {
+	info := pullRequestInfo{
+		Number:    strconv.FormatInt(mr.IID, 10),
+		Title:     mr.Title,
+		Author:    mr.Author.Username,
+		Draft:     mr.Draft,
+		HeadSHA:   mr.SHA,
+		Closed:    isClosedState(mr.State),
+		Labels:    mr.Labels,
+		UpdatedAt: mr.UpdatedAt,
+	}
+	if mr.MergedBy != nil {
+		info.MergedBy = mr.MergedBy.Username
+	}
+
+	return info
+}
+
+type gitlabNote struct {
+	ID     int64  `json:"id"`
+	Body   string `json:"body"`
+	System bool   `json:"system"`
+}
+
+type gitlabCommit struct {
+	AuthorName     string `json:"author_name"`
+	AuthorEmail    string `json:"author_email"`
+	CommitterName  string `json:"committer_name"`
+	CommitterEmail string `json:"committer_email"`
+}
+
+type gitlabDiff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	Diff        string `json:"diff"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+func (d *gitlabDiff) status() string {
+	switch {
+	case d.NewFile:
+		return "added"
+	case d.DeletedFile:
+		return "removed"
+	case d.RenamedFile:
+		return "renamed"
+	}
+
+	return "modified"
+}
+
+// gitlabLabelEvent is a resource label event of a merge request.
+type gitlabLabelEvent struct {
+	User  *gitlabUser `json:"user"`
+	Label *struct {
+		Name string `json:"name"`
+	} `json:"label"`
+	Action    string    `json:"action"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// gitlabCommentID is the id of a comment. The notes can't be deleted without the merge request,
+// so the iid of the merge request is a part of it.
+func gitlabCommentID(iid string, noteID int64) string {
+	return iid + "/" + strconv.FormatInt(noteID, 10)
+}
+
+// gitlabClient implements iClient by the REST APIs of GitLab. The organization is the namespace
+// of the project, which can be a subgroup such as 'group/subgroup'.
+type gitlabClient struct {
+	genericEvents
+	rest   *restClient
+	logger *logrus.Entry
+}
+
+func newGitLabClient(token []byte, apiURL string, logger *logrus.Entry) *gitlabClient {
+	t := strings.TrimSpace(string(token))
+	return &gitlabClient{
+		rest: newRESTClient(apiURL, func(req *http.Request) {
+			req.Header.Set("PRIVATE-TOKEN", t)
+		}, logger),
+		logger: logger,
+	}
+}
+
+func (c *gitlabClient) projectPath(org, repo string, elem ...string) string {
+	p := "projects/" + url.PathEscape(org+"/"+repo)
+	if len(elem) > 0 {
+		p += "/" + strings.Join(elem, "/")
+	}
+
+	return p
+}
+
+func (c *gitlabClient) mrPath(org, repo, number string, elem ...string) string {
+	return c.projectPath(org, repo, append([]string{"merge_requests", number}, elem...)...)
+}
+
+func (c *gitlabClient) getMergeRequest(org, repo, number string) (*gitlabMergeRequest, bool) {
+	var mr gitlabMergeRequest
+	if !c.rest.do(http.MethodGet, c.mrPath(org, repo, number), nil, nil, &mr) {
+		return nil, false
+	}
+
+	return &mr, true
+}
+
+func (c *gitlabClient) updateMergeRequest(org, repo, number string, body map[string]any) bool {
+	return c.rest.do(http.MethodPut, c.mrPath(org, repo, number), nil, body, nil)
+}
+
+// userIDs looks up the ids of the users, which are required to change the assignees and the reviewers.
+func (c *gitlabClient) userIDs(logins []string) ([]int64, bool) {
+	ids := make([]int64, 0, len(logins))
+	for _, login := range logins {
+		var users []gitlabUser
+		if !c.rest.do(http.MethodGet, "users", url.Values{"username": []string{login}}, nil, &users) {
+			return nil, false
+		}
+		if len(users) == 0 {
+			c.logger.Warningf("the user %s is not found", login)
+			continue
+		}
+		ids = append(ids, users[0].ID)
+	}
+
+	return ids, true
+}
+
+// changeUsers adds the users to or removes them from the assignees or the reviewers of the merge request.
+func (c *gitlabClient) changeUsers(org, repo, number, field string, logins []string, add bool) bool {
+	if len(logins) == 0 {
+		return false
+	}
+
+	mr, ok := c.getMergeRequest(org, repo, number)
+	if !ok {
+		return false
+	}
+	current := mr.Assignees
+	if field == "reviewer_ids" {
+		current = mr.Reviewers
+	}
+
+	ids := sets.New[int64]()
+	for i := range current {
+		ids.Insert(current[i].ID)
+	}
+
+	v, ok := c.userIDs(logins)
+	if !ok {
+		return false
+	}
+	if add {
+		ids.Insert(v...)
+	} else {
+		ids.Delete(v...)
+	}
+
+	return c.updateMergeRequest(org, repo, number, map[string]any{field: sets.List(ids)})
+}
+
+func (c *gitlabClient) CreatePRComment(org, repo, number, comment string) (success bool) {
+	return c.rest.do(http.MethodPost, c.mrPath(org, repo, number, "notes"), nil,
+		map[string]string{"body": comment}, nil)
+}
+
+func (c *gitlabClient) AddPRLabels(org, repo, number string, labels []string) (success bool) {
+	if len(labels) == 0 {
+		return
+	}
+
+	return c.updateMergeRequest(org, repo, number, map[string]any{"add_labels": strings.Join(labels, ",")})
+}
+
+func (c *gitlabClient) RemovePRLabels(org, repo, number string, labels []string) (success bool) {
+	if len(labels) == 0 {
+		return
+	}
+
+	return c.updateMergeRequest(org, repo, number, map[string]any{"remove_labels": strings.Join(labels, ",")})
+}
+
+func (c *gitlabClient) GetPullRequestCommits(org, repo, number string) (result []client.PRCommit, success bool) {
+	commits, success := listAll[gitlabCommit](c.rest, c.mrPath(org, repo, number, "commits"), nil)
+	for i := range commits {
+		v := &commits[i]
+		result = append(result, client.PRCommit{
+			AuthorName:     v.AuthorName,
+			AuthorEmail:    v.AuthorEmail,
+			CommitterName:  v.CommitterName,
+			CommitterEmail: v.CommitterEmail,
+		})
+	}
+	return
+}
+
+// ListPullRequestComments lists the comments of the users, the system notes are skipped.
+func (c *gitlabClient) ListPullRequestComments(org, repo, number string) (result []client.PRComment, success bool) {
+	notes, success := listAll[gitlabNote](c.rest, c.mrPath(org, repo, number, "notes"), nil)
+	for i := range notes {
+		if notes[i].System {
+			continue
+		}
+		result = append(result, client.PRComment{
+			ID:   gitlabCommentID(number, notes[i].ID),
+			Body: notes[i].Body,
+		})
+	}
+	return
+}
+
+// DeletePRComment deletes the comment whose id is made by gitlabCommentID.
+func (c *gitlabClient) DeletePRComment(org, repo, commentID string) (success bool) {
+	number, noteID, ok := strings.Cut(commentID, "/")
+	if !ok {
+		c.logger.Errorf("invalid comment id: %s", commentID)
+		return false
+	}
+
+	return c.rest.do(http.MethodDelete, c.mrPath(org, repo, number, "notes", noteID), nil, nil, nil)
+}
+
+func (c *gitlabClient) CheckCLASignature(urlStr string) (signState string, success bool) {
+	return checkCLASignature(urlStr, c.logger)
+}
+
+// CheckPermission checks whether the user is a member of the project who can push to it,
+// the inherited members of the groups are included.
+func (c *gitlabClient) CheckPermission(org, repo, username string) (pass, success bool) {
+	ids, ok := c.userIDs([]string{username})
+	if !ok {
+		return false, false
+	}
+	if len(ids) == 0 {
+		return false, true
+	}
+
+	var member struct {
+		AccessLevel int `json:"access_level"`
+	}
+	path := c.projectPath(org, repo, "members", "all", strconv.FormatInt(ids[0], 10))
+	if err := c.rest.send(http.MethodGet, path, nil, nil, &member); err != nil {
+		if isNotFound(err) {
+			return false, true
+		}
+		c.logger.WithError(err).Errorf("failed to get the member %s", username)
+		return false, false
+	}
+
+	return member.AccessLevel >= gitlabDeveloperAccess, true
+}
+
+func (c *gitlabClient) GetPullRequestLabels(org, repo, number string) (result []string, success bool) {
+	mr, ok := c.getMergeRequest(org, repo, number)
+	if !ok {
+		return nil, false
+	}
+
+	return mr.Labels, true
+}
+
+// MergePullRequest merges the merge request. GitLab decides whether it is merged by a merge commit,
+// or by a fast-forward merge by the settings of the project, so only squash is applied.
+func (c *gitlabClient) MergePullRequest(org, repo, number, mergeMethod string) (success bool) {
+	return c.rest.do(http.MethodPut, c.mrPath(org, repo, number, "merge"), nil,
+		map[string]bool{"squash": mergeMethod == mergeMethodSquash}, nil)
+}
+
+// ListPullRequestOperationLogs builds the logs of adding and removing labels by the resource label events,
+// because GitLab doesn't provide the operation logs.
+func (c *gitlabClient) ListPullRequestOperationLogs(org, repo, number string) (
+	result []client.PullRequestOperationLog, success bool,
+) {
+	events, success := listAll[gitlabLabelEvent](c.rest, c.mrPath(org, repo, number, "resource_label_events"), nil)
+	for i := range events {
+		e := &events[i]
+		if e.Label == nil || e.User == nil {
+			continue
+		}
+
+		var action string
+		switch e.Action {
+		case "add":
+			action = ActionAddLabel
+		case "remove":
+			action = ActionRemoveLabel
+		default:
+			continue
+		}
+
+		result = append(result, client.PullRequestOperationLog{
+			CreatedAt: e.CreatedAt,
+			UpdatedAt: e.CreatedAt,
+			Content:   action + " " + e.Label.Name,
+			Action:    e.Action,
+			UserName:  e.User.Username,
+		})
+	}
+	return
+}
+
+func (c *gitlabClient) GetPullRequestChanges(org, repo, number string) (result []client.CommitFile, success bool) {
+	diffs, success := listAll[gitlabDiff](c.rest, c.mrPath(org, repo, number, "diffs"), nil)
+	for i := range diffs {
+		d := &diffs[i]
+		status := d.status()
+
+		// the diff is empty if the file is binary or too large
+		tooLarge := d.Diff == "" && status == "modified"
+		result = append(result, client.CommitFile{
+			Filename:         newString(d.NewPath),
+			Status:           newString(status),
+			PreviousFilename: newString(d.OldPath),
+			Patch: &client.CommitPatch{
+				Diff:     newString(d.Diff),
+				OldPath:  newString(d.OldPath),
+				NewPath:  newString(d.NewPath),
+				TooLarge: &tooLarge,
+			},
+		})
+	}
+	return
+}
+
+func (c *gitlabClient) AssignPullRequest(org, repo, number string, logins []string) (success bool) {
+	return c.changeUsers(org, repo, number, "assignee_ids", logins, true)
+}
+
+func (c *gitlabClient) UnassignPullRequest(org, repo, number string, logins []string) (success bool) {
+	return c.changeUsers(org, repo, number, "assignee_ids", logins, false)
+}
+
+func (c *gitlabClient) RequestPullRequestReviewers(org, repo, number string, logins []string) (success bool) {
+	return c.changeUsers(org, repo, number, "reviewer_ids", logins, true)
+}
+
+// AddCommentReaction awards the emoji to the note, the reactions of GitHub are translated into the emoji.
+func (c *gitlabClient) AddCommentReaction(org, repo, number, commentID, reaction string) (success bool) {
+	_, noteID, ok := strings.Cut(commentID, "/")
+	if !ok {
+		c.logger.Errorf("invalid comment id: %s", commentID)
+		return false
+	}
+
+	name := reaction
+	if v, ok := gitlabEmoji[reaction]; ok {
+		name = v
+	}
+
+	return c.rest.do(http.MethodPost, c.mrPath(org, repo, number, "notes", noteID, "award_emoji"), nil,
+		map[string]string{"name": name}, nil)
+}
+
+func (c *gitlabClient) GetCurrentUser() (login string, success bool) {
+	var v gitlabUser
+	if !c.rest.do(http.MethodGet, "user", nil, nil, &v) {
+		return "", false
+	}
+
+	return v.Username, true
+}
+
+func (c *gitlabClient) ClosePullRequest(org, repo, number string) (success bool) {
+	return c.updateMergeRequest(org, repo, number, map[string]any{"state_event": "close"})
+}
+
+func (c *gitlabClient) ReopenPullRequest(org, repo, number string) (success bool) {
+	return c.updateMergeRequest(org, repo, number, map[string]any{"state_event": "reopen"})
+}
+
+func (c *gitlabClient) GetPullRequestInfo(org, repo, number string) (result pullRequestInfo, success bool) {
+	mr, ok := c.getMergeRequest(org, repo, number)
+	if !ok {
+		return result, false
+	}
+
+	return mr.toInfo(), true
+}
+
+func (c *gitlabClient) UpdatePullRequestTitle(org, repo, number, title string) (success bool) {
+	return c.updateMergeRequest(org, repo, number, map[string]any{"title": title})
+}
+
+func (c *gitlabClient) ListOpenPullRequests(org, repo string) (result []pullRequestInfo, success bool) {
+	mrs, success := listAll[gitlabMergeRequest](c.rest, c.projectPath(org, repo, "merge_requests"),
+		url.Values{"state": []string{"opened"}})
+	for i := range mrs {
+		result = append(result, mrs[i].toInfo())
+	}
+	return
+}
+
+// splitProjectPath splits the full path of a project into the namespace and the name.
+func splitProjectPath(path string) (org, repo string, err error) {
+	org, repo, ok := splitRepo(path)
+	if !ok {
+		return "", "", fmt.Errorf("invalid project path: %s", path)
+	}
+
+	return org, repo, nil
+}
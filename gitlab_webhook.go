@@ -0,0 +1,203 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/opensourceways/robot-framework-lib/framework"
+)
+
+const (
+	gitlabHeaderEvent = "X-Gitlab-Event"
+	gitlabHeaderUUID  = "X-Gitlab-Event-UUID"
+	gitlabHeaderToken = "X-Gitlab-Token"
+
+	gitlabEventMergeRequest = "Merge Request Hook"
+	gitlabEventNote         = "Note Hook"
+
+	gitlabNoteableMergeRequest = "MergeRequest"
+	gitlabStateOpened          = "opened"
+)
+
+type gitlabProject struct {
+	PathWithNamespace string `json:"path_with_namespace"`
+}
+
+type gitlabMergeRequestEvent struct {
+	User             gitlabUser    `json:"user"`
+	Project          gitlabProject `json:"project"`
+	ObjectAttributes struct {
+		IID          int64  `json:"iid"`
+		State        string `json:"state"`
+		Action       string `json:"action"`
+		URL          string `json:"url"`
+		SourceBranch string `json:"source_branch"`
+		TargetBranch string `json:"target_branch"`
+		// OldRev is set only when the source branch is pushed
+		OldRev string `json:"oldrev"`
+	} `json:"object_attributes"`
+	Changes struct {
+		Labels *json.RawMessage `json:"labels"`
+	} `json:"changes"`
+}
+
+type gitlabNoteEvent struct {
+	User             gitlabUser    `json:"user"`
+	Project          gitlabProject `json:"project"`
+	ObjectAttributes struct {
+		ID           int64  `json:"id"`
+		Note         string `json:"note"`
+		NoteableType string `json:"noteable_type"`
+		Action       string `json:"action"`
+		URL          string `json:"url"`
+		CreatedAt    string `json:"created_at"`
+		UpdatedAt    string `json:"updated_at"`
+	} `json:"object_attributes"`
+	MergeRequest *struct {
+		IID          int64  `json:"iid"`
+		State        string `json:"state"`
+		SourceBranch string `json:"source_branch"`
+		TargetBranch string `json:"target_branch"`
+	} `json:"merge_request"`
+}
+
+// gitlabWebhook translates the webhooks of GitLab. The webhooks don't carry the author of the merge request,
+// which is got by the client when it is needed.
+type gitlabWebhook struct{}
+
+// verify checks the secret token of the webhook, GitLab doesn't sign the payload.
+func (gitlabWebhook) verify(r *http.Request, _, secret []byte) bool {
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get(gitlabHeaderToken)), secret) == 1
+}
+
+func (w gitlabWebhook) translate(r *http.Request, payload []byte) (*client.GenericEvent, error) {
+	var evt *client.GenericEvent
+	var err error
+
+	switch r.Header.Get(gitlabHeaderEvent) {
+	case gitlabEventMergeRequest:
+		evt, err = w.translateMergeRequest(payload)
+	case gitlabEventNote:
+		evt, err = w.translateNote(payload)
+	}
+	if evt != nil {
+		evt.EventGUID = newString(r.Header.Get(gitlabHeaderUUID))
+	}
+
+	return evt, err
+}
+
+func (gitlabWebhook) translateMergeRequest(payload []byte) (*client.GenericEvent, error) {
+	var e gitlabMergeRequestEvent
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, err
+	}
+
+	attrs := &e.ObjectAttributes
+	state, action, detail := eventStateOpened, eventActionUpdate, ""
+	switch attrs.Action {
+	case "open":
+		action = eventActionOpen
+	case "reopen":
+		action = eventActionReopen
+	case "update":
+		if attrs.OldRev != "" {
+			detail = eventDetailSourceUpdate
+		} else if e.Changes.Labels != nil {
+			detail = eventDetailUpdateLabel
+		}
+	case "close":
+		state, action = eventStateClosed, eventActionClose
+	case "merge":
+		state, action = eventStateMerged, eventActionMerge
+	case "approval", "approved":
+		// both are sent when the approval completes the required ones, the second one is a replay
+		action, detail = eventActionReview, reviewStateApproved
+	case "unapproval", "unapproved":
+		action, detail = eventActionReview, reviewStateDismissed
+	default:
+		return nil, nil
+	}
+	if action == eventActionReview && attrs.State != gitlabStateOpened {
+		state = eventStateClosed
+	}
+
+	org, repo, err := splitProjectPath(e.Project.PathWithNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	evt := &client.GenericEvent{}
+	evt.EventType = newString(framework.PullRequestEvent)
+	evt.State = newString(state)
+	evt.Action = newString(action)
+	evt.ActionDetail = newString(detail)
+	evt.Org = newString(org)
+	evt.Repo = newString(repo)
+	evt.HtmlURL = newString(attrs.URL)
+	evt.Base = newString(attrs.TargetBranch)
+	evt.Head = newString(attrs.SourceBranch)
+	evt.Number = newString(strconv.FormatInt(attrs.IID, 10))
+	if action == eventActionReview {
+		// a user approves a merge request at most once, and revokes it by the unapproval
+		evt.CommentID = newString("approval/" + e.User.Username)
+		evt.Commenter = newString(e.User.Username)
+	}
+
+	return evt, nil
+}
+
+func (gitlabWebhook) translateNote(payload []byte) (*client.GenericEvent, error) {
+	var e gitlabNoteEvent
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, err
+	}
+
+	attrs := &e.ObjectAttributes
+	if attrs.NoteableType != gitlabNoteableMergeRequest || e.MergeRequest == nil {
+		// the comments on the issues, the commits and the snippets are not handled
+		return nil, nil
+	}
+
+	var noteAction string
+	switch attrs.Action {
+	case "", "create":
+	case "update":
+		noteAction = noteActionUpdate
+	default:
+		return nil, nil
+	}
+
+	org, repo, err := splitProjectPath(e.Project.PathWithNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	state := eventStateOpened
+	if e.MergeRequest.State != gitlabStateOpened {
+		state = eventStateClosed
+	}
+
+	number := strconv.FormatInt(e.MergeRequest.IID, 10)
+	evt := &client.GenericEvent{}
+	evt.EventType = newString(framework.NoteEvent)
+	evt.State = newString(state)
+	evt.ActionDetail = newString(noteAction)
+	evt.Org = newString(org)
+	evt.Repo = newString(repo)
+	evt.HtmlURL = newString(attrs.URL)
+	evt.Base = newString(e.MergeRequest.TargetBranch)
+	evt.Head = newString(e.MergeRequest.SourceBranch)
+	evt.Number = newString(number)
+	evt.CommentID = newString(gitlabCommentID(number, attrs.ID))
+	evt.CommentKind = newString(client.CommentOnPR)
+	evt.Comment = newString(attrs.Note)
+	evt.Commenter = newString(e.User.Username)
+	evt.CreateTime = newString(attrs.CreatedAt)
+	evt.UpdateTime = newString(attrs.UpdatedAt)
+
+	return evt, nil
+}
@@ -3,6 +3,7 @@ module github.com/opensourceways/robot-universal-review
 go 1.21
 
 require (
+	github.com/opensourceways/go-gitcode v0.2.0
 	github.com/opensourceways/robot-framework-lib v0.2.2
 	github.com/opensourceways/server-common-lib v1.0.0
 	github.com/sirupsen/logrus v1.9.3
@@ -11,7 +12,6 @@ require (
 
 require (
 	github.com/go-resty/resty/v2 v2.11.0 // indirect
-	github.com/opensourceways/go-gitcode v0.2.0 // indirect
 	golang.org/x/net v0.33.0 // indirect
 	golang.org/x/sys v0.28.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
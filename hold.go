@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	holdLabel = "do-not-merge/hold"
+
+	commentAddHold = `***%s*** was added to this pull request by: ***%s***. :raised_hand:
+**Reason:** %s
+The pull request will not be merged until the label is removed by "/unhold".`
+	commentRemoveHold = `***%s*** was removed in this pull request by: ***%s***. :wave: `
+	msgHoldLabel      = "PR is on hold, comment \"/unhold\" to remove the label ***" + holdLabel + "***"
+	fixerHold         = "Collaborators of this repository by commenting `/unhold`."
+	descHold          = "A label which prevents the pull request from being merged."
+)
+
+var regHoldArg = regexp.MustCompile(`^.*$`)
+
+func init() {
+	commands.register(&command{
+		name:    "hold",
+		args:    "[reason | cancel]",
+		argsReg: regHoldArg,
+		role:    roleCollaborator,
+		help: "Add the `" + holdLabel + "` label to prevent the pull request from being merged. " +
+			"`/hold cancel` is the same as `/unhold`.",
+		handler: func(bot *robot, c *commandContext) error {
+			if c.isCancel() {
+				return bot.removeHold(c.commenter, c.org, c.repo, c.number)
+			}
+			if err := bot.addHold(c.commenter, strings.Join(c.args, " "), c.org, c.repo, c.number); err != nil {
+				return err
+			}
+			c.labelsAdded(holdLabel)
+			return nil
+		},
+	})
+	commands.register(&command{
+		name: "unhold",
+		role: roleCollaborator,
+		help: "Remove the `" + holdLabel + "` label.",
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.removeHold(c.commenter, c.org, c.repo, c.number)
+		},
+	})
+}
+
+func (bot *robot) addHold(commenter, reason, org, repo, number string) error {
+	logrus.Infof("addHold, commenter: %s, org: %s, repo: %s, number: %s", commenter, org, repo, number)
+	if bot.getPRLabelSet(org, repo, number).Has(holdLabel) {
+		// the command may be replayed, or the pull request has been held by others
+		return nil
+	}
+	if reason == "" {
+		reason = "not given"
+	}
+
+	if ok := bot.cli.AddPRLabels(org, repo, number, []string{holdLabel}); !ok {
+		return fmt.Errorf("failed to add label on pull request")
+	}
+	if ok := bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentAddHold, holdLabel, commenter, reason)); !ok {
+		return fmt.Errorf("failed to comment on pull request")
+	}
+	return nil
+}
+
+func (bot *robot) removeHold(commenter, org, repo, number string) error {
+	logrus.Infof("removeHold, commenter: %s, org: %s, repo: %s, number: %s", commenter, org, repo, number)
+	if !bot.getPRLabelSet(org, repo, number).Has(holdLabel) {
+		return nil
+	}
+
+	if ok := bot.cli.RemovePRLabels(org, repo, number, []string{holdLabel}); !ok {
+		return fmt.Errorf("failed to remove label on pull request")
+	}
+	if ok := bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentRemoveHold, holdLabel, commenter)); !ok {
+		return fmt.Errorf("failed to comment on pull request")
+	}
+	return nil
+}
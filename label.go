@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	commentLabelsAdded   = `***%s*** %s added to this pull request by: ***%s***. :wave: `
+	commentLabelsRemoved = `***%s*** %s removed in this pull request by: ***%s***. :flushed: `
+	commentLabelsRefused = `***@%s*** can not %s these labels in this pull request. :astonished:
+%s`
+
+	reasonLabelProtected   = "***%s*** is managed by the robot, please use the corresponding command."
+	reasonLabelMaintainers = "***%s*** can only be used by the collaborators of this repository."
+	reasonLabelNotAllowed  = "***%s*** is not allowed to be used by the command."
+)
+
+var regLabelsArg = regexp.MustCompile(`^\S+(\s+\S+)*$`)
+
+func init() {
+	commands.register(&command{
+		name:    "label",
+		args:    "<label> ...",
+		argsReg: regLabelsArg,
+		role:    roleAnyone,
+		help: "Add the labels to the pull request. Anyone can use the labels which have the prefixes " +
+			"allowed by the repository, others are used by the collaborators only.",
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.handleLabel(c, true)
+		},
+	})
+	commands.register(&command{
+		name:    "remove-label",
+		args:    "<label> ...",
+		argsReg: regLabelsArg,
+		role:    roleAnyone,
+		help:    "Remove the labels from the pull request. The same rules as `/label` are applied.",
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.handleLabel(c, false)
+		},
+	})
+}
+
+func (bot *robot) handleLabel(c *commandContext, add bool) error {
+	logrus.Infof("handleLabel, add: %t, commenter: %s, org: %s, repo: %s, number: %s",
+		add, c.commenter, c.org, c.repo, c.number)
+
+	var labels, reasons []string
+	isCollaborator := false
+	checked := false
+	for _, l := range sets.List(sets.New[string](c.args...)) {
+		if isProtectedLabel(c.repoCnf, l) {
+			reasons = append(reasons, fmt.Sprintf(reasonLabelProtected, l))
+			continue
+		}
+
+		if s := bot.caps.labelProblem(l); s != "" && add {
+			reasons = append(reasons, s)
+			continue
+		}
+
+		// the labels for maintainers take precedence, they may match the prefixes for anyone
+		if !c.repoCnf.isLabelForMaintainers(l) {
+			if c.repoCnf.isLabelForAnyone(l) {
+				labels = append(labels, l)
+			} else {
+				reasons = append(reasons, fmt.Sprintf(reasonLabelNotAllowed, l))
+			}
+			continue
+		}
+
+		if !checked {
+			pass, err := bot.hasRole(roleCollaborator, c)
+			if err != nil {
+				return err
+			}
+			isCollaborator, checked = pass, true
+		}
+		if !isCollaborator {
+			reasons = append(reasons, fmt.Sprintf(reasonLabelMaintainers, l))
+			continue
+		}
+		labels = append(labels, l)
+	}
+
+	action := "add"
+	if !add {
+		action = "remove"
+	}
+	if len(reasons) > 0 {
+		bot.cli.CreatePRComment(c.org, c.repo, c.number,
+			fmt.Sprintf(commentLabelsRefused, c.commenter, action, strings.Join(reasons, "\n")))
+	}
+
+	if len(labels) == 0 {
+		return nil
+	}
+
+	return bot.changeLabels(c, labels, add)
+}
+
+func (bot *robot) changeLabels(c *commandContext, labels []string, add bool) error {
+	existing := bot.getPRLabelSet(c.org, c.repo, c.number)
+	if add {
+		labels = sets.List(sets.New[string](labels...).Difference(existing))
+	} else {
+		labels = sets.List(sets.New[string](labels...).Intersection(existing))
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+
+	format := commentLabelsAdded
+	if add {
+		if ok := bot.cli.AddPRLabels(c.org, c.repo, c.number, labels); !ok {
+			return fmt.Errorf("failed to add label on pull request")
+		}
+	} else {
+		if ok := bot.cli.RemovePRLabels(c.org, c.repo, c.number, labels); !ok {
+			return fmt.Errorf("failed to remove label on pull request")
+		}
+		format = commentLabelsRemoved
+	}
+
+	verb := "was"
+	if len(labels) > 1 {
+		verb = "were"
+	}
+	if add {
+		c.labelsAdded(labels...)
+	}
+	if ok := bot.cli.CreatePRComment(c.org, c.repo, c.number,
+		fmt.Sprintf(format, strings.Join(labels, ", "), verb, c.commenter)); !ok {
+		return fmt.Errorf("failed to comment on pull request")
+	}
+	return nil
+}
+
+// isProtectedLabel checks whether the label is used to decide merging, which can't be changed by /label.
+// The case is ignored, since the labels are case-insensitive on some platforms, such as GitLab and Gitea.
+func isProtectedLabel(repoCnf *repoConfig, label string) bool {
+	if l := strings.ToLower(label); strings.HasPrefix(l, lgtmLabel) || l == approvedLabel {
+		return true
+	}
+
+	for _, l := range repoCnf.LabelsForMerge {
+		if strings.EqualFold(l, label) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	commentIllegalLabelsRemoved = `***%s*** can not be added manually, so %s removed. :astonished:
+Please use the corresponding command instead.`
+	unknownUser = "unknown"
+)
+
+// guardReviewLabels reacts to the labels changed by hand as soon as the labels are updated.
+// The protected labels which are not added by the legal operator are removed, and the review
+// labels removed by hand are recorded, so that they won't be added back by rebuilding.
+func (bot *robot) guardReviewLabels(repoCnf *repoConfig, org, repo, number string) error {
+	ops, ok := bot.cli.ListPullRequestOperationLogs(org, repo, number)
+	if !ok {
+		return errListOperationLogs
+	}
+
+	labels := bot.getPRLabelSet(org, repo, number)
+	bot.recordRemovedReviews(org, repo, number, labels, ops)
+
+	return bot.removeIllegalLabels(repoCnf, org, repo, number, labels, ops)
+}
+
+func (bot *robot) removeIllegalLabels(
+	repoCnf *repoConfig, org, repo, number string, labels sets.Set[string], ops []client.PullRequestOperationLog,
+) error {
+	legalOperator := bot.legalOperator(repoCnf, org)
+	if legalOperator == "" {
+		// nobody is legal, the labels are checked when merging
+		return nil
+	}
+
+	var illegal, who []string
+	for _, l := range sets.List(labels) {
+		if !isProtectedLabel(repoCnf, l) {
+			continue
+		}
+
+		// the log may be not ready yet when the event is received, it is checked again when merging
+		log, ok := getLatestLog(ops, l)
+		if !ok || !isNewlyAdded(ops, log) {
+			// the labels added before have been checked by their own events
+			continue
+		}
+		if !repoCnf.canAddLabel(legalOperator, log.who, l) {
+			illegal = append(illegal, l)
+			who = append(who, log.who)
+		}
+	}
+	if len(illegal) == 0 {
+		return nil
+	}
+
+	logrus.Infof("removeIllegalLabels, labels: %v, added by: %v, org: %s, repo: %s, number: %s",
+		illegal, who, org, repo, number)
+	if ok := bot.cli.RemovePRLabels(org, repo, number, illegal); !ok {
+		return fmt.Errorf("failed to remove label on pull request")
+	}
+
+	verb := "it was"
+	if len(illegal) > 1 {
+		verb = "they were"
+	}
+	if ok := bot.cli.CreatePRComment(org, repo, number,
+		fmt.Sprintf(commentIllegalLabelsRemoved, strings.Join(illegal, ", "), verb)); !ok {
+		return fmt.Errorf("failed to comment on pull request")
+	}
+	return nil
+}
+
+// isNewlyAdded checks whether the label is added by the latest adding of the labels in the log,
+// which is what the current event is for. The labels added at once are logged in the same second.
+func isNewlyAdded(ops []client.PullRequestOperationLog, log labelLog) bool {
+	var latest time.Time
+	for i := range ops {
+		if strings.HasPrefix(ops[i].Content, ActionAddLabel) && ops[i].CreatedAt.After(latest) {
+			latest = ops[i].CreatedAt
+		}
+	}
+
+	return latest.Sub(log.t) < time.Second
+}
+
+// recordRemovedReviews records the cancels of the review labels which are in the records but not on the pull request.
+// The robot records its own removing before it happens, so they are removed by hand.
+func (bot *robot) recordRemovedReviews(
+	org, repo, number string, labels sets.Set[string], ops []client.PullRequestOperationLog,
+) {
+	st := bot.store.state(org, repo, number)
+
+	remover := func(label string) string {
+		if log, ok := getLatestActionLog(ops, ActionRemoveLabel, label); ok {
+			return log.who
+		}
+		return unknownUser
+	}
+
+	for l := range st.lgtm {
+		if !labels.Has(l) {
+			bot.saveReview(reviewRecord{
+				Org: org, Repo: repo, Number: number, User: remover(l), Command: reviewActionLgtmCancel, Label: l,
+			})
+		}
+	}
+
+	if len(st.approve) > 0 && !labels.Has(approvedLabel) {
+		bot.saveReview(reviewRecord{
+			Org: org, Repo: repo, Number: number, User: remover(approvedLabel),
+			Command: reviewActionApproveCancel, Label: approvedLabel,
+		})
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"hash/fnv"
 	"regexp"
 	"strings"
 
@@ -10,9 +11,7 @@ import (
 )
 
 const (
-	// the gitee platform limits the maximum length of label to 20.
-	labelLenLimit = 20
-	lgtmLabel     = "lgtm"
+	lgtmLabel = "lgtm"
 
 	commentAddLGTMBySelf            = "***lgtm*** can not be added in your self-own pull request. :astonished:"
 	commentClearLabelCaseByPRUpdate = `New code changes of pr are detected and remove these labels ***%s***. :flushed: `
@@ -27,23 +26,56 @@ Please contact to the collaborators in this repository.`
 	commentRemovedLabel = `***%s*** was removed in this pull request by: ***%s***. :flushed: `
 )
 
-var (
-	regAddLgtm    = regexp.MustCompile(`(?mi)^/lgtm\s*$`)
-	regRemoveLgtm = regexp.MustCompile(`(?mi)^/lgtm cancel\s*$`)
-)
+var regCancelArg = regexp.MustCompile(`(?i)^(cancel)?$`)
+
+func init() {
+	commands.register(&command{
+		name:    "lgtm",
+		args:    "[cancel]",
+		argsReg: regCancelArg,
+		role:    roleAuthor,
+		help: "Add or remove the `lgtm` label. The author of the pull request can only remove the label, " +
+			"but cannot add it.",
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.handleLGTM(c)
+		},
+		denied: func(c *commandContext) string {
+			if c.isCancel() {
+				return fmt.Sprintf(commentNoPermissionForLabel, c.commenter, "remove", lgtmLabel)
+			}
+			return fmt.Sprintf(commentNoPermissionForLgtmLabel, c.commenter)
+		},
+	})
+}
 
-func (bot *robot) handleLGTM(configmap *repoConfig, comment, commenter, author, org, repo, number string) error {
-	if regAddLgtm.MatchString(comment) {
-		return bot.addLGTM(commenter, author, org, repo, number, configmap.LgtmCountsRequired)
+func (bot *robot) handleLGTM(c *commandContext) error {
+	label := genLGTMLabel(bot.caps, c.commenter, c.repoCnf.LgtmCountsRequired)
+	if c.isCancel() {
+		if err := bot.removeLGTM(c.commenter, c.author, c.org, c.repo, c.number, c.repoCnf.LgtmCountsRequired); err != nil {
+			return err
+		}
+		if c.commenter == c.author {
+			// the author removes all the lgtm labels
+			bot.recordReview(c, reviewActionLgtmCancel, "")
+			return nil
+		}
+		for _, l := range genLGTMLabels(bot.caps, c.commenter, c.repoCnf.LgtmCountsRequired) {
+			bot.recordReview(c, reviewActionLgtmCancel, l)
+		}
+		return nil
 	}
 
-	if regRemoveLgtm.MatchString(comment) {
-		return bot.removeLGTM(commenter, author, org, repo, number, configmap.LgtmCountsRequired)
+	if err := bot.addLGTM(c.commenter, c.author, c.org, c.repo, c.number, c.repoCnf.LgtmCountsRequired); err != nil {
+		return err
+	}
+	if c.commenter != c.author {
+		c.labelsAdded(label)
+		bot.recordReview(c, reviewActionLgtm, label)
 	}
-
 	return nil
 }
 
+// addLGTM adds the lgtm label, the commenter must be checked as a collaborator if it isn't the author.
 func (bot *robot) addLGTM(commenter, author, org, repo, number string, lgtmCounts uint) error {
 	logrus.Infof("addLGTM, commenter: %s, author: %s, org: %s, repo: %s, number: %s", commenter, author, org, repo, number)
 	if author == commenter {
@@ -52,52 +84,80 @@ func (bot *robot) addLGTM(commenter, author, org, repo, number string, lgtmCount
 		}
 		return nil
 	}
-	if pass, ok := bot.cli.CheckPermission(org, repo, commenter); pass && ok {
-		label := genLGTMLabel(commenter, lgtmCounts)
 
-		if ok := bot.cli.AddPRLabels(org, repo, number, []string{label}); !ok {
-			return fmt.Errorf("failed to add label on pull request")
-		}
-		if ok := bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentAddLabel, label, commenter)); !ok {
-			return fmt.Errorf("failed to comment on pull request")
-		}
-	} else {
-		bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentNoPermissionForLgtmLabel, commenter))
+	label := genLGTMLabel(bot.caps, commenter, lgtmCounts)
+	if bot.getPRLabelSet(org, repo, number).HasAny(genLGTMLabels(bot.caps, commenter, lgtmCounts)...) {
+		// the command may be replayed, or the reviewer has added the label
+		return nil
+	}
+	if ok := bot.cli.AddPRLabels(org, repo, number, []string{label}); !ok {
+		return fmt.Errorf("failed to add label on pull request")
+	}
+	if ok := bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentAddLabel, label, commenter)); !ok {
+		return fmt.Errorf("failed to comment on pull request")
 	}
 	return nil
 
 }
 
+// removeLGTM removes the lgtm label, the commenter must be checked as a collaborator if it isn't the author.
 func (bot *robot) removeLGTM(commenter, author, org, repo, number string, lgtmCounts uint) error {
 	logrus.Infof("removeLGTM, commenter: %s, author: %s, org: %s, repo: %s, number: %s", commenter, author, org, repo, number)
+	labels := bot.getPRLabelSet(org, repo, number)
 	if author == commenter {
-		bot.cli.RemovePRLabels(org, repo, number, getLGTMLabelsOnPR(bot.getPRLabelSet(org, repo, number)))
+		if v := getLGTMLabelsOnPR(labels); len(v) > 0 {
+			bot.cli.RemovePRLabels(org, repo, number, v)
+		}
 	} else {
-		if pass, ok := bot.cli.CheckPermission(org, repo, commenter); pass && ok {
-			label := genLGTMLabel(commenter, lgtmCounts)
-			bot.cli.RemovePRLabels(org, repo, number, []string{label})
-			bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentRemovedLabel, label, commenter))
-		} else {
-			bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentNoPermissionForLabel, commenter, "remove", lgtmLabel))
+		v := sets.List(labels.Intersection(sets.New[string](genLGTMLabels(bot.caps, commenter, lgtmCounts)...)))
+		if len(v) == 0 {
+			return nil
 		}
-
+		bot.cli.RemovePRLabels(org, repo, number, v)
+		bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentRemovedLabel, strings.Join(v, ", "), commenter))
 	}
 	return nil
 }
 
-func genLGTMLabel(commenter string, lgtmCount uint) string {
+// genLGTMLabel returns the lgtm label of the commenter, which is made usable on the platform.
+func genLGTMLabel(caps *capabilities, commenter string, lgtmCount uint) string {
 	if lgtmCount <= 1 {
 		return lgtmLabel
 	}
 
-	l := fmt.Sprintf("%s-%s", lgtmLabel, strings.ToLower(commenter))
-	if len(l) > labelLenLimit {
-		return l[:labelLenLimit]
+	user := strings.ToLower(commenter)
+	l := fmt.Sprintf("%s-%s", lgtmLabel, user)
+	if caps.invalidLabelChars != nil {
+		l = caps.invalidLabelChars.ReplaceAllString(l, "-")
+	}
+	if n := caps.labelLenLimit; n > 0 && len(l) > n {
+		// a hash of the user name is kept at the end, so that the users with a long common prefix don't collide
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(user))
+		suffix := fmt.Sprintf("-%04x", h.Sum32()&0xffff)
+		return l[:n-len(suffix)] + suffix
 	}
 
 	return l
 }
 
+// genLGTMLabels returns the lgtm label of the commenter, and the one which was truncated without the hash
+// by the earlier versions if it is different, so the labels added before the upgrade are still recognized.
+func genLGTMLabels(caps *capabilities, commenter string, lgtmCount uint) []string {
+	label := genLGTMLabel(caps, commenter, lgtmCount)
+	r := []string{label}
+
+	if n := caps.labelLenLimit; n > 0 && lgtmCount > 1 {
+		// the earlier versions neither hashed nor replaced the characters
+		l := fmt.Sprintf("%s-%s", lgtmLabel, strings.ToLower(commenter))
+		if len(l) > n && l[:n] != label {
+			r = append(r, l[:n])
+		}
+	}
+
+	return r
+}
+
 func getLGTMLabelsOnPR(labels sets.Set[string]) []string {
 	var r []string
 
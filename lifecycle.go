@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/opensourceways/server-common-lib/interrupts"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	lifecycleLabelPrefix = "lifecycle/"
+	lifecycleStale       = "stale"
+	lifecycleRotten      = "rotten"
+	lifecycleFrozen      = "frozen"
+	staleLabel           = lifecycleLabelPrefix + lifecycleStale
+	rottenLabel          = lifecycleLabelPrefix + lifecycleRotten
+	frozenLabel          = lifecycleLabelPrefix + lifecycleFrozen
+
+	defaultLifecycleInterval = 60
+	day                      = 24 * time.Hour
+
+	commentMarkStale = `This pull request has been inactive for %d days, so it is marked as ***%s***. :sleeping:
+It will be marked as ***%s*** after %d more days of inactivity.
+Comment "/remove-lifecycle stale" to remove the label, or "/lifecycle frozen" to keep it open.`
+	commentMarkRotten = `This pull request has been inactive for %d days since it was marked as ***%s***, ` +
+		`so it is marked as ***%s***. :sleeping:
+It will be closed after %d more days of inactivity.
+Comment "/remove-lifecycle rotten" to remove the label, or "/lifecycle frozen" to keep it open.`
+	commentCloseRotten = `This pull request has been inactive for %d days since it was marked as ***%s***, ` +
+		`so it is closed. :wave:
+Comment "/reopen" to reopen it if it is still needed.`
+	commentLifecycleChanged = `***%s*** was %s by: ***%s***. :wave: `
+)
+
+var regLifecycleArg = regexp.MustCompile(`(?i)^(frozen|stale|rotten)$`)
+
+func init() {
+	commands.register(&command{
+		name:    "lifecycle",
+		args:    "frozen | stale | rotten",
+		argsReg: regLifecycleArg,
+		role:    roleAuthor,
+		help: "Add the `" + lifecycleLabelPrefix + "*` label. The pull request with `" + frozenLabel +
+			"` is never marked as stale or closed for inactivity.",
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.handleLifecycle(c, true)
+		},
+	})
+	commands.register(&command{
+		name:    "remove-lifecycle",
+		args:    "frozen | stale | rotten",
+		argsReg: regLifecycleArg,
+		role:    roleAuthor,
+		help:    "Remove the `" + lifecycleLabelPrefix + "*` label.",
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.handleLifecycle(c, false)
+		},
+	})
+}
+
+func (bot *robot) handleLifecycle(c *commandContext, add bool) error {
+	logrus.Infof("handleLifecycle, add: %t, commenter: %s, org: %s, repo: %s, number: %s",
+		add, c.commenter, c.org, c.repo, c.number)
+	label := lifecycleLabelPrefix + strings.ToLower(c.args[0])
+	labels := bot.getPRLabelSet(c.org, c.repo, c.number)
+
+	action := "added"
+	if add {
+		if labels.Has(label) {
+			return nil
+		}
+		if ok := bot.cli.AddPRLabels(c.org, c.repo, c.number, []string{label}); !ok {
+			return fmt.Errorf("failed to add label on pull request")
+		}
+		c.labelsAdded(label)
+	} else {
+		if !labels.Has(label) {
+			return nil
+		}
+		if ok := bot.cli.RemovePRLabels(c.org, c.repo, c.number, []string{label}); !ok {
+			return fmt.Errorf("failed to remove label on pull request")
+		}
+		action = "removed"
+	}
+
+	if ok := bot.cli.CreatePRComment(c.org, c.repo, c.number,
+		fmt.Sprintf(commentLifecycleChanged, label, action, c.commenter)); !ok {
+		return fmt.Errorf("failed to comment on pull request")
+	}
+	return nil
+}
+
+// removeLifecycleLabels removes the stale and rotten labels because the pull request becomes active.
+func (bot *robot) removeLifecycleLabels(org, repo, number string) {
+	labels := bot.getPRLabelSet(org, repo, number)
+	if v := sets.List(labels.Intersection(sets.New[string](staleLabel, rottenLabel))); len(v) > 0 {
+		bot.cli.RemovePRLabels(org, repo, number, v)
+	}
+}
+
+// startLifecycle starts the job which marks the inactive pull requests as stale or rotten, and closes them.
+func (bot *robot) startLifecycle() {
+	if bot.cnf == nil || len(bot.cnf.lifecycleRepos()) == 0 {
+		return
+	}
+
+	interval := bot.cnf.LifecycleInterval
+	if interval <= 0 {
+		interval = defaultLifecycleInterval
+	}
+
+	interrupts.TickLiteral(bot.runLifecycle, time.Duration(interval)*time.Minute)
+}
+
+func (bot *robot) runLifecycle() {
+	for _, v := range bot.cnf.lifecycleRepos() {
+		org, repo, _ := splitRepo(v)
+		logger := bot.log.WithFields(logrus.Fields{"org": org, "repo": repo})
+
+		repoCnf := bot.cnf.get(org, repo)
+		if repoCnf == nil {
+			continue
+		}
+
+		prs, ok := bot.cli.ListOpenPullRequests(org, repo)
+		if !ok {
+			logger.Warning("lifecycle, failed to list open pull requests")
+			continue
+		}
+
+		for i := range prs {
+			pr := &prs[i]
+			bot.events.run(prKey(org, repo, pr.Number), logger, func() {
+				if err := bot.updateLifecycle(&repoCnf.Lifecycle, org, repo, pr); err != nil {
+					logger.WithError(err).Warningf("lifecycle, number: %s", pr.Number)
+				}
+			})
+		}
+	}
+}
+
+func (bot *robot) updateLifecycle(cnf *lifecycleConfig, org, repo string, pr *pullRequestInfo) error {
+	labels := sets.New[string](pr.Labels...)
+	if labels.Has(frozenLabel) {
+		return nil
+	}
+
+	// the last activity is the later one of updating the pull request and the last lifecycle step,
+	// in case the labels don't change the update time.
+	last := pr.UpdatedAt
+	if labels.Has(staleLabel) || labels.Has(rottenLabel) {
+		ops, ok := bot.cli.ListPullRequestOperationLogs(org, repo, pr.Number)
+		if !ok {
+			return errListOperationLogs
+		}
+		for _, l := range []string{staleLabel, rottenLabel} {
+			if log, ok := getLatestLog(ops, l); ok && log.t.After(last) {
+				last = log.t
+			}
+		}
+	}
+	inactive := int(time.Since(last) / day)
+
+	switch {
+	case labels.Has(rottenLabel):
+		if inactive < cnf.CloseDays {
+			return nil
+		}
+		if ok := bot.cli.ClosePullRequest(org, repo, pr.Number); !ok {
+			return fmt.Errorf("failed to close pull request")
+		}
+		bot.cli.CreatePRComment(org, repo, pr.Number, fmt.Sprintf(commentCloseRotten, inactive, rottenLabel))
+
+	case labels.Has(staleLabel):
+		if inactive < cnf.RottenDays {
+			return nil
+		}
+		if ok := bot.cli.AddPRLabels(org, repo, pr.Number, []string{rottenLabel}); !ok {
+			return fmt.Errorf("failed to add label on pull request")
+		}
+		bot.cli.RemovePRLabels(org, repo, pr.Number, []string{staleLabel})
+		bot.cli.CreatePRComment(org, repo, pr.Number,
+			fmt.Sprintf(commentMarkRotten, inactive, staleLabel, rottenLabel, cnf.CloseDays))
+
+	default:
+		if inactive < cnf.StaleDays {
+			return nil
+		}
+		if ok := bot.cli.AddPRLabels(org, repo, pr.Number, []string{staleLabel}); !ok {
+			return fmt.Errorf("failed to add label on pull request")
+		}
+		bot.cli.CreatePRComment(org, repo, pr.Number,
+			fmt.Sprintf(commentMarkStale, inactive, staleLabel, rottenLabel, cnf.RottenDays))
+	}
+
+	return nil
+}
@@ -15,8 +15,10 @@ package main
 
 import (
 	"flag"
-	"github.com/opensourceways/robot-framework-lib/framework"
 	"os"
+
+	"github.com/opensourceways/robot-framework-lib/framework"
+	"github.com/sirupsen/logrus"
 )
 
 const component = "robot-universal-review"
@@ -25,11 +27,32 @@ func main() {
 
 	opt := new(robotOptions)
 	// Gather the necessary arguments from command line for project startup
-	cnf, token := opt.gatherOptions(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:]...)
+	cnf, tokens := opt.gatherOptions(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:]...)
 	if opt.interrupt {
 		return
 	}
 
-	bot := newRobot(cnf, token)
-	framework.StartupServer(framework.NewServer(bot, opt.service), opt.service)
+	store, err := newReviewStore(opt.storePath)
+	if err != nil {
+		logrus.WithError(err).Error("fatal error occurred while loading review store")
+		return
+	}
+
+	bot, err := newRobot(cnf, tokens, opt, store)
+	if err != nil {
+		logrus.WithError(err).Error("fatal error occurred while creating robot")
+		_ = store.close()
+		return
+	}
+	bot.startReconciler()
+	bot.startLifecycle()
+
+	server := framework.NewServer(bot, opt.service)
+	registerWebhookAdapter(server, opt.platform, opt.service.HandlePath, opt.webhookSecret, bot.log)
+	framework.StartupServer(server, opt.service)
+
+	// all the handlers are finished after the server is shut down gracefully
+	if err := store.close(); err != nil {
+		logrus.WithError(err).Error("failed to close review store")
+	}
 }
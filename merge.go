@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -15,6 +16,40 @@ const (
 	msgInvalidLabels      = "PR should remove these labels: %s"
 	msgNotEnoughLGTMLabel = "PR needs %d lgtm labels and now gets %d"
 	ActionAddLabel        = "add label"
+	ActionRemoveLabel     = "delete label"
+)
+
+// blockingLabel is a label which always prevents the pull request from being merged
+// regardless of LabelsNotAllowMerge.
+type blockingLabel struct {
+	label string
+	// msg is the reason of not merging
+	msg string
+	// fixer tells who can remove the label and how to do it
+	fixer string
+	// desc is the default description of the label
+	desc string
+}
+
+var blockingLabels = []blockingLabel{
+	{label: holdLabel, msg: msgHoldLabel, fixer: fixerHold, desc: descHold},
+	{label: wipLabel, msg: msgWIPLabel, fixer: fixerWIP, desc: descWIP},
+}
+
+func isBlockingLabel(label string) bool {
+	for i := range blockingLabels {
+		if blockingLabels[i].label == label {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	errListOperationLogs = errors.New("failed to list pull request operation logs")
+	errMergePullRequest  = errors.New("failed to merge pull request")
+	errPRNotOpen         = errors.New("PR is not open")
 )
 
 type labelLog struct {
@@ -24,28 +59,54 @@ type labelLog struct {
 }
 
 func (bot *robot) handleMerge(configmap *repoConfig, org, repo, number string) error {
+	info, ok := bot.cli.GetPullRequestInfo(org, repo, number)
+	if !ok {
+		return fmt.Errorf("failed to get pull request")
+	}
+	if info.Closed {
+		// the event may be queued before the pull request is closed
+		return errPRNotOpen
+	}
+
 	labels := bot.getPRLabelSet(org, repo, number)
 	ops, ok := bot.cli.ListPullRequestOperationLogs(org, repo, number)
 	if !ok {
-		return fmt.Errorf("failed to list pull request operation logs")
+		return errListOperationLogs
 	}
-	if err := checkLabelsLegal(configmap, ops, labels); err != nil {
+	if err := checkLabelsLegal(configmap, bot.legalOperator(configmap, org), ops, labels); err != nil {
 		return err
 	}
+	expired, err := bot.expireReviewLabels(configmap, org, repo, number, labels, ops)
+	if err != nil {
+		return err
+	}
+	labels.Delete(expired...)
 	if reasons := isLabelMatched(configmap, labels); len(reasons) > 0 {
 		return fmt.Errorf(strings.Join(reasons, "\n\n"))
 	}
+	if err := bot.checkWIP(configmap, org, repo, number, info); err != nil {
+		return err
+	}
 
-	methodOfMerge := bot.genMergeMethod(org, repo, number)
+	methodOfMerge, _ := genMergeMethod(bot.caps, configmap, labels)
 	if ok := bot.cli.MergePullRequest(org, repo, number, methodOfMerge); !ok {
-		return fmt.Errorf("failed to merge pull request")
+		return errMergePullRequest
 	}
+	bot.saveReview(reviewRecord{Org: org, Repo: repo, Number: number, Command: reviewActionMerge, Label: methodOfMerge})
 	return nil
 }
 
 func isLabelMatched(configmap *repoConfig, labels sets.Set[string]) []string {
 	var reasons []string
+	for i := range blockingLabels {
+		if labels.Has(blockingLabels[i].label) {
+			reasons = append(reasons, blockingLabels[i].msg)
+		}
+	}
 	for _, l := range configmap.LabelsNotAllowMerge {
+		if isBlockingLabel(l) {
+			continue
+		}
 		if labels.Has(l) {
 			reasons = append(reasons, fmt.Sprintf(msgInvalidLabels, l))
 		}
@@ -74,7 +135,9 @@ func isLabelMatched(configmap *repoConfig, labels sets.Set[string]) []string {
 	return reasons
 }
 
-func checkLabelsLegal(configmap *repoConfig, ops []client.PullRequestOperationLog, labels sets.Set[string]) error {
+func checkLabelsLegal(
+	configmap *repoConfig, legalOperator string, ops []client.PullRequestOperationLog, labels sets.Set[string],
+) error {
 	reason := make([]string, 0, len(labels))
 	needs := sets.New[string](approvedLabel)
 	needs.Insert(configmap.LabelsForMerge...)
@@ -83,10 +146,9 @@ func checkLabelsLegal(configmap *repoConfig, ops []client.PullRequestOperationLo
 	} else {
 		needs.Insert(getLGTMLabelsOnPR(labels)...)
 	}
-	legalOperator := configmap.LegalOperator
 	for label := range labels {
 		if ok := needs.Has(label); ok {
-			if s := isLabelLegal(ops, label, legalOperator); s != "" {
+			if s := isLabelLegal(configmap, ops, label, legalOperator); s != "" {
 				reason = append(reason, s)
 			}
 		}
@@ -101,13 +163,13 @@ func checkLabelsLegal(configmap *repoConfig, ops []client.PullRequestOperationLo
 	return nil
 }
 
-func isLabelLegal(ops []client.PullRequestOperationLog, label string, legalOperator string) string {
+func isLabelLegal(configmap *repoConfig, ops []client.PullRequestOperationLog, label string, legalOperator string) string {
 	labelLog, ok := getLatestLog(ops, label)
 	if !ok {
 		return fmt.Sprintf("The corresponding operation log is missing. you should delete "+
 			"the label **%s** and add it again by correct way", label)
 	}
-	if labelLog.who != legalOperator {
+	if !configmap.canAddLabel(legalOperator, labelLog.who, label) {
 		return fmt.Sprintf("%s You can't add **%s** by yourself, you should delete "+
 			"the label and add it again by correct way", labelLog.who, labelLog.label)
 	}
@@ -115,12 +177,16 @@ func isLabelLegal(ops []client.PullRequestOperationLog, label string, legalOpera
 }
 
 func getLatestLog(ops []client.PullRequestOperationLog, label string) (labelLog, bool) {
+	return getLatestActionLog(ops, ActionAddLabel, label)
+}
+
+func getLatestActionLog(ops []client.PullRequestOperationLog, action, label string) (labelLog, bool) {
 	var t time.Time
 	index := -1
 
 	for i := range ops {
 		op := &ops[i]
-		if !strings.HasPrefix(op.Content, ActionAddLabel) || !strings.Contains(op.Content, label) {
+		if !strings.HasPrefix(op.Content, action) || !strings.Contains(op.Content, label) {
 			continue
 		}
 
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	mergeMethodLabelPrefix = "merge/"
+	mergeMethodMerge       = "merge"
+	mergeMethodSquash      = "squash"
+	mergeMethodRebase      = "rebase"
+	mergeMethodDefault     = "default"
+
+	commentMergeMethod           = `The pull request will be merged by ***%s***%s, set by: ***%s***. :wave: `
+	commentMergeMethodNotAllowed = `***%s*** is not allowed in this repository, the valid methods are: ***%s***. :astonished: `
+)
+
+var regMergeMethodArg = regexp.MustCompile(`(?i)^(merge|squash|rebase|default|cancel)?$`)
+
+func init() {
+	commands.register(&command{
+		name:    "merge-method",
+		aliases: []string{mergeMethodSquash, mergeMethodRebase},
+		args:    "<merge|squash|rebase|default>",
+		argsReg: regMergeMethodArg,
+		role:    roleCollaborator,
+		help: "Set the method to merge the pull request by the `merge/*` label, `default` means the method " +
+			"configured for the repository. `/squash [cancel]` and `/rebase [cancel]` are kept as aliases. " +
+			"Without any method, the current one is shown.",
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.handleMergeMethod(c)
+		},
+	})
+}
+
+// requestedMergeMethod returns the method requested by the command, an empty string means showing the current one.
+func (c *commandContext) requestedMergeMethod() string {
+	arg := ""
+	if len(c.args) > 0 {
+		arg = strings.ToLower(c.args[0])
+	}
+
+	// the aliases, such as '/squash' and '/squash cancel'
+	if name := strings.ToLower(c.name); name == mergeMethodSquash || name == mergeMethodRebase {
+		if arg == "cancel" {
+			return mergeMethodDefault
+		}
+		return name
+	}
+
+	if arg == "cancel" {
+		return mergeMethodDefault
+	}
+
+	return arg
+}
+
+func (bot *robot) handleMergeMethod(c *commandContext) error {
+	logrus.Infof("handleMergeMethod, commenter: %s, org: %s, repo: %s, number: %s", c.commenter, c.org, c.repo, c.number)
+	method := c.requestedMergeMethod()
+	if method == "" {
+		return bot.commentMergeMethod(c, bot.getPRLabelSet(c.org, c.repo, c.number))
+	}
+
+	allowed := bot.caps.allowedMergeMethods(c.repoCnf)
+	if method != mergeMethodDefault && !sets.New[string](allowed...).Has(method) {
+		bot.cli.CreatePRComment(c.org, c.repo, c.number,
+			fmt.Sprintf(commentMergeMethodNotAllowed, method, strings.Join(allowed, ", ")))
+		return nil
+	}
+
+	labels, err := bot.swapMergeMethodLabel(c.org, c.repo, c.number, method)
+	if err != nil {
+		return err
+	}
+
+	return bot.commentMergeMethod(c, labels)
+}
+
+// swapMergeMethodLabel replaces the merge/* labels by the one of the method, and returns the resulting labels.
+// The removed labels are restored if the new one can't be added.
+func (bot *robot) swapMergeMethodLabel(org, repo, number, method string) (sets.Set[string], error) {
+	labels := bot.getPRLabelSet(org, repo, number)
+	old := getMergeMethodLabels(labels)
+
+	want := sets.New[string]()
+	if method != mergeMethodDefault {
+		want.Insert(mergeMethodLabelPrefix + method)
+	}
+
+	// the new label is added first, so there is no moment without a method label
+	added := sets.List(want.Difference(old))
+	if len(added) > 0 {
+		if ok := bot.cli.AddPRLabels(org, repo, number, added); !ok {
+			return nil, fmt.Errorf("failed to add label on pull request")
+		}
+		labels.Insert(added...)
+	}
+
+	if v := sets.List(old.Difference(want)); len(v) > 0 {
+		if ok := bot.cli.RemovePRLabels(org, repo, number, v); !ok {
+			if len(added) > 0 {
+				bot.cli.RemovePRLabels(org, repo, number, added)
+			}
+			return nil, fmt.Errorf("failed to remove label on pull request")
+		}
+		labels.Delete(v...)
+	}
+
+	return labels, nil
+}
+
+func (bot *robot) commentMergeMethod(c *commandContext, labels sets.Set[string]) error {
+	method, byLabel := genMergeMethod(bot.caps, c.repoCnf, labels)
+	note := ""
+	if !byLabel {
+		note = " which is the default method of this repository"
+	}
+
+	if ok := bot.cli.CreatePRComment(c.org, c.repo, c.number,
+		fmt.Sprintf(commentMergeMethod, method, note, c.commenter)); !ok {
+		return fmt.Errorf("failed to comment on pull request")
+	}
+	return nil
+}
+
+func getMergeMethodLabels(labels sets.Set[string]) sets.Set[string] {
+	r := sets.New[string]()
+	for l := range labels {
+		if strings.HasPrefix(l, mergeMethodLabelPrefix) {
+			r.Insert(l)
+		}
+	}
+
+	return r
+}
+
+// genMergeMethod returns the method to merge the pull request and whether it is decided by the merge/* label.
+// The methods which the platform doesn't support are ignored.
+func genMergeMethod(caps *capabilities, configmap *repoConfig, labels sets.Set[string]) (string, bool) {
+	allowed := sets.New[string](caps.allowedMergeMethods(configmap)...)
+	for _, l := range sets.List(getMergeMethodLabels(labels)) {
+		if m := strings.TrimPrefix(l, mergeMethodLabelPrefix); allowed.Has(m) {
+			return m, true
+		}
+	}
+
+	if configmap.MergeMethod != "" && caps.supportsMergeMethod(configmap.MergeMethod) {
+		return configmap.MergeMethod, false
+	}
+
+	return mergeMethodMerge, false
+}
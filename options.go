@@ -15,8 +15,12 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"github.com/opensourceways/robot-framework-lib/client"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/opensourceways/robot-framework-lib/config"
 	"github.com/opensourceways/server-common-lib/secret"
@@ -28,21 +32,96 @@ type robotOptions struct {
 	delToken  bool
 	interrupt bool
 	tokenPath string
+	storePath string
+	// orgTokenPaths is the paths to the token files of the organizations
+	orgTokenPaths orgTokenPaths
+
+	deliveryTTL      time.Duration
+	deliveryCapacity int
+	eventWorkers     int
+
+	platform          string
+	apiURL            string
+	webhookSecretPath string
+	webhookSecret     []byte
+}
+
+// orgTokenPaths maps the organization to the path to the file containing its token.
+// It is set by the flag repeatedly in the form of 'org=path'.
+type orgTokenPaths map[string]string
+
+func (v orgTokenPaths) String() string {
+	r := make([]string, 0, len(v))
+	for org, path := range v {
+		r = append(r, org+"="+path)
+	}
+	sort.Strings(r)
+
+	return strings.Join(r, ",")
+}
+
+func (v orgTokenPaths) Set(s string) error {
+	org, path, ok := strings.Cut(s, "=")
+	if !ok || org == "" || path == "" {
+		return fmt.Errorf("invalid token path of organization: %s, it should be org=path", s)
+	}
+	if _, ok := v[org]; ok {
+		return fmt.Errorf("duplicate token path of organization: %s", org)
+	}
+
+	v[org] = path
+	return nil
 }
 
 func (o *robotOptions) addFlags(fs *flag.FlagSet) {
 	o.service.AddFlagsComposite(fs)
 	fs.StringVar(
 		&o.tokenPath, "token-path", "",
-		"Path to the file containing the token secret.",
+		"Path to the file containing the token secret. "+
+			"It is used for the organizations without a token given by --org-token-path.",
+	)
+	o.orgTokenPaths = orgTokenPaths{}
+	fs.Var(
+		o.orgTokenPaths, "org-token-path",
+		"The organization and the path to the file containing its token in the form of org=path. "+
+			"It can be given for each organization.",
 	)
 	fs.BoolVar(
 		&o.delToken, "del-token", true,
 		"An flag to delete token secret file.",
 	)
+	fs.StringVar(
+		&o.storePath, "review-store-path", "",
+		"Path to the file storing the review records. They are only kept in memory if it is empty.",
+	)
+	fs.DurationVar(
+		&o.deliveryTTL, "delivery-ttl", defaultDeliveryTTL,
+		"How long a webhook delivery is remembered to skip the retried ones.",
+	)
+	fs.IntVar(
+		&o.deliveryCapacity, "delivery-capacity", defaultDeliveryCapacity,
+		"The maximum number of webhook deliveries remembered.",
+	)
+	fs.StringVar(
+		&o.platform, "platform", platformGitCode,
+		"The code hosting platform which the robot works on, one of "+platformNames()+".",
+	)
+	fs.StringVar(
+		&o.apiURL, "platform-api-url", "",
+		"The base url of the REST APIs of the platform. The one of the public service is used if it is empty.",
+	)
+	fs.StringVar(
+		&o.webhookSecretPath, "webhook-secret-path", "",
+		"Path to the file containing the secret to verify the webhooks. "+
+			"It is required by the platforms other than gitcode.",
+	)
+	fs.IntVar(
+		&o.eventWorkers, "event-workers", defaultEventWorkers,
+		"The number of workers handling the events. The events of the same pull request are handled one by one.",
+	)
 }
 
-func (o *robotOptions) validateFlags() (*configuration, []byte) {
+func (o *robotOptions) validateFlags() (*configuration, *tokenSet) {
 	if err := o.service.ValidateComposite(); err != nil {
 		logrus.Errorf("invalid service options, err:%s", err.Error())
 		o.interrupt = true
@@ -52,35 +131,74 @@ func (o *robotOptions) validateFlags() (*configuration, []byte) {
 	configmap, err := config.NewConfigmapAgent(&configuration{}, o.service.ConfigFile)
 	if err != nil {
 		logrus.Errorf("load config, err:%s", err.Error())
+		o.interrupt = true
 		return nil, nil
 	}
 
-	token, err := secret.LoadSingleSecret(o.tokenPath)
+	if _, ok := platforms[o.platform]; !ok {
+		logrus.Errorf("unknown platform: %s, it should be one of %s", o.platform, platformNames())
+		o.interrupt = true
+		return nil, nil
+	}
+
+	if o.webhookSecretPath != "" {
+		if o.webhookSecret, err = secret.LoadSingleSecret(o.webhookSecretPath); err != nil {
+			logrus.WithError(err).Error("fatal error occurred while loading webhook secret")
+			o.interrupt = true
+			return nil, nil
+		}
+	}
+	if platforms[o.platform].translator != nil && len(o.webhookSecret) == 0 {
+		// the webhooks can't be verified without it, so anyone could send the events
+		logrus.Errorf("--webhook-secret-path is required by the platform: %s", o.platform)
+		o.interrupt = true
+		return nil, nil
+	}
+
+	return configmap.GetConfigmap().(*configuration), o.loadTokens()
+}
+
+// loadTokens loads the default token and the ones of the organizations.
+// The default one is optional if there is any of the organizations.
+func (o *robotOptions) loadTokens() *tokenSet {
+	tokens := &tokenSet{orgs: make(map[string][]byte, len(o.orgTokenPaths))}
+	if o.tokenPath != "" || len(o.orgTokenPaths) == 0 {
+		tokens.def = o.loadToken(o.tokenPath)
+	}
+	for org, path := range o.orgTokenPaths {
+		tokens.orgs[org] = o.loadToken(path)
+	}
+
+	return tokens
+}
+
+func (o *robotOptions) loadToken(path string) []byte {
+	token, err := secret.LoadSingleSecret(path)
 	if err != nil {
-		logrus.WithError(err).Error("fatal error occurred while loading token")
+		logrus.WithError(err).Errorf("fatal error occurred while loading token %s", path)
 		o.interrupt = true
 	}
 	if o.delToken {
-		if err = os.Remove(o.tokenPath); err != nil {
-			logrus.WithError(err).Error("fatal error occurred while deleting token")
+		if err = os.Remove(path); err != nil {
+			logrus.WithError(err).Errorf("fatal error occurred while deleting token %s", path)
 			o.interrupt = true
 		}
 	}
 
-	return configmap.GetConfigmap().(*configuration), token
+	return token
 }
 
 // gatherOptions gather the necessary arguments from command line for project startup.
-// It returns the configuration and the token to using for subsequent processes.
-func (o *robotOptions) gatherOptions(fs *flag.FlagSet, args ...string) (*configuration, []byte) {
+// It returns the configuration and the tokens to using for subsequent processes.
+func (o *robotOptions) gatherOptions(fs *flag.FlagSet, args ...string) (*configuration, *tokenSet) {
 	o.addFlags(fs)
 	_ = fs.Parse(args)
-	cnf, token := o.validateFlags()
+	cnf, tokens := o.validateFlags()
 
 	if cnf != nil {
 		client.SetSigInfoBaseURL(cnf.SigInfoURL)
 		client.SetCommunityName(cnf.CommunityName)
 	}
 
-	return cnf, token
+	return cnf, tokens
 }
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/opensourceways/robot-framework-lib/utils"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// tokenSet is the tokens which the robot works with. The token of an organization is used for its repositories,
+// and the default one is used for the others. Either of them may be missing.
+type tokenSet struct {
+	def  []byte
+	orgs map[string][]byte
+}
+
+// orgClient is the client working under the identity behind a token.
+type orgClient struct {
+	iClient
+	login string
+}
+
+// orgClients routes the requests to the client of the organization, or the default client if the organization
+// has no token. It is an iClient, so the handlers need not know which client they are using.
+// The default client is nil if there is no default token, so the organizations without a token must be
+// refused before the requests, see login.
+type orgClients struct {
+	def  *orgClient
+	orgs map[string]*orgClient
+}
+
+func newOrgClients(p *platform, tokens *tokenSet, apiURL string, logger *logrus.Entry) (*orgClients, error) {
+	if tokens == nil {
+		return nil, fmt.Errorf("no token is given")
+	}
+
+	if apiURL == "" {
+		apiURL = p.defaultAPIURL
+	}
+
+	newClient := func(name string, token []byte) (*orgClient, error) {
+		cli := p.newClient(token, apiURL, logger)
+		login, ok := cli.GetCurrentUser()
+		if !ok || login == "" {
+			return nil, fmt.Errorf("failed to get the user behind %s", name)
+		}
+		logger.Infof("%s belongs to %s", name, login)
+
+		return &orgClient{iClient: cli, login: login}, nil
+	}
+
+	c := &orgClients{orgs: map[string]*orgClient{}}
+	if tokens.def != nil {
+		cli, err := newClient("the default token", tokens.def)
+		if err != nil {
+			return nil, err
+		}
+		c.def = cli
+	}
+	for org, token := range tokens.orgs {
+		cli, err := newClient("the token of "+org, token)
+		if err != nil {
+			return nil, err
+		}
+		c.orgs[org] = cli
+	}
+
+	if c.def == nil && len(c.orgs) == 0 {
+		return nil, fmt.Errorf("no token is given")
+	}
+
+	return c, nil
+}
+
+// of returns the client of the organization, it is nil if there is no token for it.
+func (c *orgClients) of(org string) *orgClient {
+	if cli, ok := c.orgs[org]; ok {
+		return cli
+	}
+
+	return c.def
+}
+
+// any returns a client for the requests which don't belong to an organization.
+func (c *orgClients) any() *orgClient {
+	if c.def != nil {
+		return c.def
+	}
+
+	orgs := make([]string, 0, len(c.orgs))
+	for org := range c.orgs {
+		orgs = append(orgs, org)
+	}
+	sort.Strings(orgs)
+
+	return c.orgs[orgs[0]]
+}
+
+// login returns the user behind the token of the organization, it is empty if there is no token for it.
+func (c *orgClients) login(org string) string {
+	if cli := c.of(org); cli != nil {
+		return cli.login
+	}
+
+	return ""
+}
+
+// validate checks that each organization in the configuration has a token, and the user behind it
+// can write the repositories of the organization. The organizations configured without the repositories
+// are only checked for the token.
+func (c *orgClients) validate(cnf *configuration) error {
+	orgs := c.knownOrgs(cnf)
+
+	for i := range cnf.ConfigItems {
+		for _, v := range cnf.ConfigItems[i].Repos {
+			org, repo, ok := splitRepo(v)
+			if !ok || orgs.Has(v) {
+				// an organization without the repositories, which may be a subgroup of GitLab
+				org, repo = v, ""
+			}
+			cli := c.of(org)
+			if cli == nil {
+				return fmt.Errorf("no token for the organization: %s", org)
+			}
+			if repo == "" {
+				continue
+			}
+
+			pass, ok := cli.CheckPermission(org, repo, cli.login)
+			if !ok {
+				return fmt.Errorf("failed to check the permission of %s to %s", cli.login, v)
+			}
+			if !pass {
+				return fmt.Errorf("%s can't write %s", cli.login, v)
+			}
+		}
+	}
+
+	return nil
+}
+
+// knownOrgs returns the entries of the configuration which are known to be the organizations.
+// An entry with slashes is ambiguous since an organization may be a subgroup of GitLab, so it is
+// an organization only if it has its own token, or it is the organization of another entry.
+func (c *orgClients) knownOrgs(cnf *configuration) sets.Set[string] {
+	r := sets.New[string]()
+	for org := range c.orgs {
+		r.Insert(org)
+	}
+
+	for i := range cnf.ConfigItems {
+		item := &cnf.ConfigItems[i]
+		for _, v := range append(append([]string{}, item.Repos...), item.ExcludedRepos...) {
+			if org, _, ok := splitRepo(v); ok {
+				r.Insert(org)
+			}
+		}
+	}
+	for _, v := range cnf.Reconciler.Repos {
+		if org, _, ok := splitRepo(v); ok {
+			r.Insert(org)
+		}
+	}
+
+	return r
+}
+
+func (c *orgClients) CreatePRComment(org, repo, number, comment string) (success bool) {
+	return c.of(org).CreatePRComment(org, repo, number, comment)
+}
+
+func (c *orgClients) AddPRLabels(org, repo, number string, labels []string) (success bool) {
+	return c.of(org).AddPRLabels(org, repo, number, labels)
+}
+
+func (c *orgClients) RemovePRLabels(org, repo, number string, labels []string) (success bool) {
+	return c.of(org).RemovePRLabels(org, repo, number, labels)
+}
+
+func (c *orgClients) GetPullRequestCommits(org, repo, number string) (result []client.PRCommit, success bool) {
+	return c.of(org).GetPullRequestCommits(org, repo, number)
+}
+
+func (c *orgClients) ListPullRequestComments(org, repo, number string) (result []client.PRComment, success bool) {
+	return c.of(org).ListPullRequestComments(org, repo, number)
+}
+
+func (c *orgClients) DeletePRComment(org, repo, commentID string) (success bool) {
+	return c.of(org).DeletePRComment(org, repo, commentID)
+}
+
+func (c *orgClients) CheckCLASignature(urlStr string) (signState string, success bool) {
+	return c.any().CheckCLASignature(urlStr)
+}
+
+func (c *orgClients) CheckIfPRCreateEvent(evt *client.GenericEvent) (yes bool) {
+	return c.of(utils.GetString(evt.Org)).CheckIfPRCreateEvent(evt)
+}
+
+func (c *orgClients) CheckIfPRSourceCodeUpdateEvent(evt *client.GenericEvent) (yes bool) {
+	return c.of(utils.GetString(evt.Org)).CheckIfPRSourceCodeUpdateEvent(evt)
+}
+
+func (c *orgClients) CheckPermission(org, repo, username string) (pass, success bool) {
+	return c.of(org).CheckPermission(org, repo, username)
+}
+
+func (c *orgClients) GetPullRequestLabels(org, repo, number string) (result []string, success bool) {
+	return c.of(org).GetPullRequestLabels(org, repo, number)
+}
+
+func (c *orgClients) MergePullRequest(org, repo, number, mergeMethod string) (success bool) {
+	return c.of(org).MergePullRequest(org, repo, number, mergeMethod)
+}
+
+func (c *orgClients) CheckIfPRReopenEvent(evt *client.GenericEvent) (yes bool) {
+	return c.of(utils.GetString(evt.Org)).CheckIfPRReopenEvent(evt)
+}
+
+func (c *orgClients) CheckIfPRLabelsUpdateEvent(evt *client.GenericEvent) (yes bool) {
+	return c.of(utils.GetString(evt.Org)).CheckIfPRLabelsUpdateEvent(evt)
+}
+
+func (c *orgClients) CheckIfPRClosedEvent(evt *client.GenericEvent) (yes bool) {
+	return c.of(utils.GetString(evt.Org)).CheckIfPRClosedEvent(evt)
+}
+
+func (c *orgClients) CheckIfPRMergedEvent(evt *client.GenericEvent) (yes bool) {
+	return c.of(utils.GetString(evt.Org)).CheckIfPRMergedEvent(evt)
+}
+
+func (c *orgClients) ListPullRequestOperationLogs(org, repo, number string) (
+	result []client.PullRequestOperationLog, success bool) {
+	return c.of(org).ListPullRequestOperationLogs(org, repo, number)
+}
+
+func (c *orgClients) GetPullRequestChanges(org, repo, number string) (result []client.CommitFile, success bool) {
+	return c.of(org).GetPullRequestChanges(org, repo, number)
+}
+
+func (c *orgClients) AssignPullRequest(org, repo, number string, logins []string) (success bool) {
+	return c.of(org).AssignPullRequest(org, repo, number, logins)
+}
+
+func (c *orgClients) UnassignPullRequest(org, repo, number string, logins []string) (success bool) {
+	return c.of(org).UnassignPullRequest(org, repo, number, logins)
+}
+
+func (c *orgClients) RequestPullRequestReviewers(org, repo, number string, logins []string) (success bool) {
+	return c.of(org).RequestPullRequestReviewers(org, repo, number, logins)
+}
+
+func (c *orgClients) ClosePullRequest(org, repo, number string) (success bool) {
+	return c.of(org).ClosePullRequest(org, repo, number)
+}
+
+func (c *orgClients) ReopenPullRequest(org, repo, number string) (success bool) {
+	return c.of(org).ReopenPullRequest(org, repo, number)
+}
+
+func (c *orgClients) GetPullRequestInfo(org, repo, number string) (result pullRequestInfo, success bool) {
+	return c.of(org).GetPullRequestInfo(org, repo, number)
+}
+
+func (c *orgClients) UpdatePullRequestTitle(org, repo, number, title string) (success bool) {
+	return c.of(org).UpdatePullRequestTitle(org, repo, number, title)
+}
+
+func (c *orgClients) ListOpenPullRequests(org, repo string) (result []pullRequestInfo, success bool) {
+	return c.of(org).ListOpenPullRequests(org, repo)
+}
+
+func (c *orgClients) AddCommentReaction(org, repo, number, commentID, reaction string) (success bool) {
+	return c.of(org).AddCommentReaction(org, repo, number, commentID, reaction)
+}
+
+// GetCurrentUser returns the user behind the default token, see login for the one of an organization.
+func (c *orgClients) GetCurrentUser() (login string, success bool) {
+	return c.any().login, true
+}
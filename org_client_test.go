@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/opensourceways/server-common-lib/config"
+)
+
+func TestOrgClientsValidateSubgroup(t *testing.T) {
+	forge := newFakeForge(scenarioRobot)
+	forge.addCollaborators("group/subgroup", "repo", scenarioRobot)
+	c := &orgClients{orgs: map[string]*orgClient{
+		"group/subgroup": {iClient: forge, login: scenarioRobot},
+	}}
+
+	cases := []struct {
+		name  string
+		repos []string
+	}{
+		{name: "the subgroup with its own token", repos: []string{"group/subgroup"}},
+		{name: "the repository of the subgroup", repos: []string{"group/subgroup/repo"}},
+	}
+
+	for _, v := range cases {
+		cnf := &configuration{ConfigItems: []repoConfig{{RepoFilter: config.RepoFilter{Repos: v.repos}}}}
+		if err := c.validate(cnf); err != nil {
+			t.Errorf("%s: %v", v.name, err)
+		}
+	}
+
+	cnf := &configuration{ConfigItems: []repoConfig{{RepoFilter: config.RepoFilter{Repos: []string{"group"}}}}}
+	if err := c.validate(cnf); err == nil {
+		t.Error("the organization without a token is accepted")
+	}
+}
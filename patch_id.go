@@ -0,0 +1,111 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/opensourceways/robot-framework-lib/utils"
+)
+
+const (
+	commentKeepLabelCaseByIdenticalContent = `New code changes of pr are detected, but the change content is identical to the reviewed one ` +
+		`(patch-id ***%s***), so these labels are kept: ***%s***. :relieved: `
+
+	// reviewActionPatch records the patch id of the pull request when it is created or updated.
+	reviewActionPatch = "patch"
+)
+
+// getPatchID computes the patch id of the pull request. It returns false if it can't be computed,
+// such as some diff is too large to be returned.
+func (bot *robot) getPatchID(org, repo, number string) (string, bool) {
+	files, ok := bot.cli.GetPullRequestChanges(org, repo, number)
+	if !ok || len(files) == 0 {
+		return "", false
+	}
+
+	return patchID(files)
+}
+
+// patchID works like 'git patch-id --stable'. The hunk headers with line numbers and the index lines
+// are ignored, so the id is the same when the pull request is rebased without changing the content.
+// The other lines are hashed as they are, since a change of the white spaces may change the code,
+// such as the indentation of Python and YAML.
+func patchID(files []client.CommitFile) (string, bool) {
+	type fileDiff struct {
+		name string
+		diff string
+	}
+
+	diffs := make([]fileDiff, 0, len(files))
+	for i := range files {
+		f := &files[i]
+		if f.Patch == nil || f.Patch.Diff == nil || (f.Patch.TooLarge != nil && *f.Patch.TooLarge) {
+			return "", false
+		}
+
+		name := utils.GetString(f.Filename)
+		if name == "" {
+			name = utils.GetString(f.Patch.NewPath)
+		}
+		diffs = append(diffs, fileDiff{
+			name: fmt.Sprintf("%s %s", utils.GetString(f.Patch.OldPath), name),
+			diff: *f.Patch.Diff,
+		})
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].name < diffs[j].name
+	})
+
+	h := sha256.New()
+	for i := range diffs {
+		_, _ = h.Write([]byte(diffs[i].name + "\n"))
+
+		for _, line := range strings.Split(diffs[i].diff, "\n") {
+			if strings.HasPrefix(line, "@@") || strings.HasPrefix(line, "index ") {
+				continue
+			}
+			_, _ = h.Write([]byte(line + "\n"))
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// savePatchID records the current patch id of the pull request, and returns it with the previous one.
+func (bot *robot) savePatchID(org, repo, number string) (cur, prev string) {
+	prev = bot.store.lastPatchID(org, repo, number)
+
+	cur, ok := bot.getPatchID(org, repo, number)
+	if ok && cur != prev {
+		bot.saveReview(reviewRecord{Org: org, Repo: repo, Number: number, Command: reviewActionPatch, PatchID: cur})
+	}
+
+	return
+}
+
+// keepLabelsIfContentIdentical checks whether the change content is the same as the reviewed one after
+// the source code is updated, and keeps the review labels if so.
+func (bot *robot) keepLabelsIfContentIdentical(org, repo, number string) bool {
+	cur, prev := bot.savePatchID(org, repo, number)
+	if cur == "" || cur != prev {
+		return false
+	}
+
+	labels := bot.getPRLabelSet(org, repo, number)
+	v := getLGTMLabelsOnPR(labels)
+	if labels.Has(approvedLabel) {
+		v = append(v, approvedLabel)
+	}
+
+	if len(v) > 0 {
+		bot.cli.CreatePRComment(org, repo, number,
+			fmt.Sprintf(commentKeepLabelCaseByIdenticalContent, shortSHA(cur), strings.Join(v, ", ")))
+	}
+
+	return true
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+)
+
+func testCommitFiles(diff string) []client.CommitFile {
+	return []client.CommitFile{{
+		Filename: newString("main.py"),
+		Patch:    &client.CommitPatch{OldPath: newString("main.py"), NewPath: newString("main.py"), Diff: newString(diff)},
+	}}
+}
+
+func TestPatchID(t *testing.T) {
+	const reviewed = "index 1a2b..3c4d 100644\n@@ -1,2 +1,3 @@\n def f():\n+    return \"a b\"\n"
+
+	cases := []struct {
+		name string
+		diff string
+		same bool
+	}{
+		{
+			name: "rebased",
+			diff: "index 5e6f..7a8b 100644\n@@ -10,2 +10,3 @@ class A:\n def f():\n+    return \"a b\"\n",
+			same: true,
+		},
+		{
+			name: "indentation changed",
+			diff: "index 1a2b..3c4d 100644\n@@ -1,2 +1,3 @@\n def f():\n+  return \"a b\"\n",
+		},
+		{
+			name: "spaces in a string literal changed",
+			diff: "index 1a2b..3c4d 100644\n@@ -1,2 +1,3 @@\n def f():\n+    return \"ab\"\n",
+		},
+	}
+
+	want, ok := patchID(testCommitFiles(reviewed))
+	if !ok {
+		t.Fatal("failed to compute the patch id")
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, ok := patchID(testCommitFiles(c.diff))
+			if !ok {
+				t.Fatal("failed to compute the patch id")
+			}
+			if (got == want) != c.same {
+				t.Errorf("the patch id is the same: %t, want %t", got == want, c.same)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/opensourceways/robot-framework-lib/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	platformGitCode = "gitcode"
+	platformGitHub  = "github"
+	platformGitLab  = "gitlab"
+	platformGitea   = "gitea"
+	platformForgejo = "forgejo"
+)
+
+// The events of the other platforms are translated into the ones of GitCode,
+// which are the values below.
+const (
+	eventStateOpened = "opened"
+	eventStateClosed = "closed"
+	eventStateMerged = "merged"
+
+	eventActionOpen   = "open"
+	eventActionUpdate = "update"
+	eventActionReopen = "reopen"
+	eventActionClose  = "close"
+	eventActionMerge  = "merge"
+
+	eventDetailSourceUpdate = "source update"
+	eventDetailUpdateLabel  = "update label"
+)
+
+// platform is a code hosting platform which the robot can work on.
+type platform struct {
+	// defaultAPIURL is the base url of the REST APIs of the public service
+	defaultAPIURL string
+	newClient     func(token []byte, apiURL string, logger *logrus.Entry) iClient
+	// translator translates the webhooks into the generic events. It is nil if the framework supports
+	// the webhooks of the platform.
+	translator webhookTranslator
+	caps       capabilities
+}
+
+var (
+	// the drafts of Gitea are the pull requests whose title has the prefix of work in progress
+	giteaCapabilities = capabilities{
+		labelLenLimit: 255,
+		mergeMethods:  allMergeMethods,
+		reactions:     true,
+	}
+
+	allMergeMethods = []string{mergeMethodMerge, mergeMethodSquash, mergeMethodRebase}
+
+	// the labels are added by the comma separated values of GitLab
+	regGitLabInvalidLabelChars = regexp.MustCompile(`,`)
+)
+
+var platforms = map[string]platform{
+	platformGitCode: {
+		defaultAPIURL: gitcodeAPIBaseURL,
+		newClient: func(token []byte, _ string, logger *logrus.Entry) iClient {
+			return newGitCodeClient(token, logger)
+		},
+		caps: capabilities{
+			labelLenLimit: 20,
+			mergeMethods:  allMergeMethods,
+			draft:         true,
+		},
+	},
+	platformGitHub: {
+		defaultAPIURL: githubAPIBaseURL,
+		newClient: func(token []byte, apiURL string, logger *logrus.Entry) iClient {
+			return newGitHubClient(token, apiURL, logger)
+		},
+		translator: githubWebhook{},
+		caps: capabilities{
+			labelLenLimit: 50,
+			mergeMethods:  allMergeMethods,
+			reactions:     true,
+			draft:         true,
+		},
+	},
+	platformGitLab: {
+		defaultAPIURL: gitlabAPIBaseURL,
+		newClient: func(token []byte, apiURL string, logger *logrus.Entry) iClient {
+			return newGitLabClient(token, apiURL, logger)
+		},
+		translator: gitlabWebhook{},
+		caps: capabilities{
+			labelLenLimit:     255,
+			invalidLabelChars: regGitLabInvalidLabelChars,
+			// rebase is decided by the settings of the project
+			mergeMethods: []string{mergeMethodMerge, mergeMethodSquash},
+			reactions:    true,
+			draft:        true,
+		},
+	},
+	platformGitea: {
+		defaultAPIURL: giteaAPIBaseURL,
+		newClient: func(token []byte, apiURL string, logger *logrus.Entry) iClient {
+			return newGiteaClient(token, apiURL, logger)
+		},
+		translator: giteaWebhook{},
+		caps:       giteaCapabilities,
+	},
+	platformForgejo: {
+		defaultAPIURL: forgejoAPIBaseURL,
+		newClient: func(token []byte, apiURL string, logger *logrus.Entry) iClient {
+			return newGiteaClient(token, apiURL, logger)
+		},
+		translator: giteaWebhook{},
+		caps:       giteaCapabilities,
+	},
+}
+
+func platformNames() string {
+	v := make([]string, 0, len(platforms))
+	for k := range platforms {
+		v = append(v, k)
+	}
+	sort.Strings(v)
+
+	return strings.Join(v, ", ")
+}
+
+// genericEvents implements the checks of the events for the platforms whose webhooks are translated.
+type genericEvents struct{}
+
+func (genericEvents) CheckIfPRCreateEvent(evt *client.GenericEvent) (yes bool) {
+	return utils.GetString(evt.State) == eventStateOpened && utils.GetString(evt.Action) == eventActionOpen
+}
+
+func (genericEvents) CheckIfPRSourceCodeUpdateEvent(evt *client.GenericEvent) (yes bool) {
+	return utils.GetString(evt.State) == eventStateOpened && utils.GetString(evt.Action) == eventActionUpdate &&
+		utils.GetString(evt.ActionDetail) == eventDetailSourceUpdate
+}
+
+func (genericEvents) CheckIfPRReopenEvent(evt *client.GenericEvent) (yes bool) {
+	return utils.GetString(evt.State) == eventStateOpened && utils.GetString(evt.Action) == eventActionReopen
+}
+
+func (genericEvents) CheckIfPRLabelsUpdateEvent(evt *client.GenericEvent) (yes bool) {
+	return utils.GetString(evt.State) == eventStateOpened && utils.GetString(evt.Action) == eventActionUpdate &&
+		utils.GetString(evt.ActionDetail) == eventDetailUpdateLabel
+}
+
+func (genericEvents) CheckIfPRClosedEvent(evt *client.GenericEvent) (yes bool) {
+	return utils.GetString(evt.State) == eventStateClosed && utils.GetString(evt.Action) == eventActionClose
+}
+
+func (genericEvents) CheckIfPRMergedEvent(evt *client.GenericEvent) (yes bool) {
+	return utils.GetString(evt.State) == eventStateMerged && utils.GetString(evt.Action) == eventActionMerge
+}
+
+type claSignature struct {
+	Data struct {
+		Signed bool `json:"signed"`
+	} `json:"data"`
+}
+
+// checkCLASignature checks the CLA signature by the service of the community, which is the same for all the platforms.
+func checkCLASignature(urlStr string, logger *logrus.Entry) (signState string, success bool) {
+	signState = client.CLASignStateUnknown
+	if urlStr == "" {
+		return
+	}
+
+	resp, err := http.Get(urlStr)
+	if err != nil {
+		logger.WithError(err).Errorf("failed to request CLA: %s", urlStr)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		logger.Errorf("failed to request CLA: %s, status: %s", urlStr, resp.Status)
+		return
+	}
+
+	var data claSignature
+	if err = json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		logger.WithError(err).Errorf("failed to decode CLA: %s", urlStr)
+		return
+	}
+
+	if data.Data.Signed {
+		return client.CLASignStateYes, true
+	}
+	return client.CLASignStateNo, true
+}
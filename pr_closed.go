@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	commentMergedSummary = `This pull request was merged by ***%s*** with the method ***%s***. :tada:
+%s
+%s`
+	reviewedBy         = "**Reviewed by:** %s"
+	mergedByRobot      = "It was merged by the robot after all the merge conditions were met."
+	mergedConditionMet = "It was merged outside the robot, and all the merge conditions were met."
+	mergedBypassed     = `It was merged outside the robot and **bypassed** these merge conditions:
+
+| | condition | who can fix it | description |
+| --- | --- | --- | --- |
+%s`
+
+	// reviewActionMerge records that the pull request is merged by the robot.
+	reviewActionMerge = "merge"
+)
+
+// handlePRClosed cleans up the state held by the robot when the pull request is closed or merged.
+func (bot *robot) handlePRClosed(repoCnf *repoConfig, org, repo, number string, merged bool) error {
+	logrus.Infof("handlePRClosed, merged: %t, org: %s, repo: %s, number: %s", merged, org, repo, number)
+
+	// the commands can't be revoked by editing the comments any more
+	bot.sources.forget(prKey(org, repo, number))
+	// the events queued before it is closed are stale, unless it is reopened
+	bot.events.drop(prKey(org, repo, number), bot.cli.CheckIfPRReopenEvent)
+
+	labels := bot.getPRLabelSet(org, repo, number)
+	if merged && repoCnf.MergedSummary {
+		// the labels are checked before the transient ones are removed
+		bot.commentMergedSummary(repoCnf, org, repo, number, labels)
+	}
+
+	// the review records are no longer needed after the summary, the labels are cleared if it is reopened
+	if err := bot.store.drop(org, repo, number); err != nil {
+		logrus.WithError(err).Errorf("failed to drop the review records of %s", prKey(org, repo, number))
+	}
+
+	if v := sets.List(getMergeMethodLabels(labels)); len(v) > 0 {
+		if ok := bot.cli.RemovePRLabels(org, repo, number, v); !ok {
+			return fmt.Errorf("failed to remove label on pull request")
+		}
+	}
+
+	return nil
+}
+
+func (bot *robot) commentMergedSummary(repoCnf *repoConfig, org, repo, number string, labels sets.Set[string]) {
+	mergedBy := "unknown"
+	if info, ok := bot.cli.GetPullRequestInfo(org, repo, number); ok && info.MergedBy != "" {
+		mergedBy = info.MergedBy
+	}
+	method, _ := genMergeMethod(bot.caps, repoCnf, labels)
+
+	conditions := mergedByRobot
+	if !bot.isMergedByRobot(org, repo, number) {
+		ops, ok := bot.cli.ListPullRequestOperationLogs(org, repo, number)
+		items := checkReadiness(repoCnf, bot.legalOperator(repoCnf, org), labels, ops, ok)
+
+		var rows []string
+		for i := range items {
+			if !items[i].passed {
+				rows = append(rows, items[i].String())
+			}
+		}
+
+		if len(rows) == 0 {
+			conditions = mergedConditionMet
+		} else {
+			conditions = fmt.Sprintf(mergedBypassed, strings.Join(rows, "\n"))
+		}
+	}
+
+	bot.cli.CreatePRComment(org, repo, number,
+		fmt.Sprintf(commentMergedSummary, mergedBy, method, bot.reviewers(org, repo, number, labels), conditions))
+}
+
+func (bot *robot) isMergedByRobot(org, repo, number string) bool {
+	for _, r := range bot.store.list(org, repo, number) {
+		if r.Command == reviewActionMerge {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reviewers tells who reviewed the pull request by the records, or by the labels if there is no record.
+func (bot *robot) reviewers(org, repo, number string, labels sets.Set[string]) string {
+	st := bot.store.state(org, repo, number)
+
+	var v []string
+	for _, r := range st.records() {
+		v = append(v, fmt.Sprintf("%s (%s)", r.User, r.Command))
+	}
+	if len(v) == 0 {
+		v = getLGTMLabelsOnPR(labels)
+		if labels.Has(approvedLabel) {
+			v = append(v, approvedLabel)
+		}
+	}
+	if len(v) == 0 {
+		v = []string{"none"}
+	}
+
+	return fmt.Sprintf(reviewedBy, strings.Join(v, ", "))
+}
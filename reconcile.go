@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/opensourceways/server-common-lib/interrupts"
+	"github.com/sirupsen/logrus"
+)
+
+// reconcileReport is the result of reconciling the open pull requests of a repository.
+type reconcileReport struct {
+	total    int
+	merged   []string
+	notReady int
+	failed   []string
+}
+
+// startReconciler starts the job which merges the ready pull requests periodically,
+// because the labels-update events may be missed when the webhooks are dropped or the robot is restarted.
+func (bot *robot) startReconciler() {
+	if bot.cnf == nil {
+		return
+	}
+
+	cnf := &bot.cnf.Reconciler
+	if cnf.Interval <= 0 || len(cnf.Repos) == 0 {
+		return
+	}
+
+	interrupts.TickLiteral(bot.reconcile, time.Duration(cnf.Interval)*time.Minute)
+}
+
+func (bot *robot) reconcile() {
+	for _, v := range bot.cnf.Reconciler.Repos {
+		org, repo, _ := splitRepo(v)
+		logger := bot.log.WithFields(logrus.Fields{"org": org, "repo": repo})
+
+		repoCnf := bot.cnf.get(org, repo)
+		if repoCnf == nil {
+			logger.Warning("reconcile, no config for this repo")
+			continue
+		}
+
+		r, err := bot.reconcileRepo(repoCnf, org, repo, logger)
+		if err != nil {
+			logger.WithError(err).Warning("reconcile")
+			continue
+		}
+
+		logger.WithFields(logrus.Fields{
+			"total":     r.total,
+			"merged":    strings.Join(r.merged, ","),
+			"not_ready": r.notReady,
+			"failed":    strings.Join(r.failed, ","),
+		}).Info("reconcile done")
+	}
+}
+
+func (bot *robot) reconcileRepo(repoCnf *repoConfig, org, repo string, logger *logrus.Entry) (reconcileReport, error) {
+	r := reconcileReport{}
+
+	prs, ok := bot.cli.ListOpenPullRequests(org, repo)
+	if !ok {
+		return r, errors.New("failed to list open pull requests")
+	}
+
+	r.total = len(prs)
+	for i := range prs {
+		number := prs[i].Number
+
+		var err error
+		bot.events.run(prKey(org, repo, number), logger, func() {
+			err = bot.handleMerge(repoCnf, org, repo, number)
+		})
+		switch {
+		case err == nil:
+			r.merged = append(r.merged, number)
+		case errors.Is(err, errListOperationLogs) || errors.Is(err, errMergePullRequest):
+			logger.WithError(err).Warningf("reconcile, number: %s", number)
+			r.failed = append(r.failed, number)
+		default:
+			r.notReady++
+		}
+	}
+
+	return r, nil
+}
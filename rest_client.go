@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const restTimeout = 30 * time.Second
+
+// restClient is a simple client of the REST APIs of a platform, the bodies are encoded as json.
+type restClient struct {
+	baseURL string
+	// auth sets the credential of the request
+	auth func(req *http.Request)
+	// limitParam is the name of the query parameter of the page size, and pageSize is the size
+	// which the platform accepts.
+	limitParam string
+	pageSize   int
+	hc         *http.Client
+	logger     *logrus.Entry
+}
+
+func newRESTClient(baseURL string, auth func(req *http.Request), logger *logrus.Entry) *restClient {
+	return &restClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/") + "/",
+		auth:       auth,
+		limitParam: "per_page",
+		pageSize:   perPage,
+		hc:         &http.Client{Timeout: restTimeout},
+		logger:     logger,
+	}
+}
+
+// do sends the request, and decodes the response into the receiver if it is not nil.
+// The response is not decoded if the receiver is a *[]byte.
+func (c *restClient) do(method, path string, query url.Values, body, receiver any) (success bool) {
+	err := c.send(method, path, query, body, receiver)
+	if err != nil {
+		c.logger.WithError(err).Errorf("failed to request %s %s", method, path)
+	}
+
+	return err == nil
+}
+
+func (c *restClient) send(method, path string, query url.Values, body, receiver any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	urlStr := c.baseURL + strings.TrimPrefix(path, "/")
+	if len(query) > 0 {
+		urlStr += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(method, urlStr, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.auth != nil {
+		c.auth(req)
+	}
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &restError{method: method, url: urlStr, status: resp.StatusCode, msg: string(msg)}
+	}
+
+	if receiver == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if raw, ok := receiver.(*[]byte); ok {
+		*raw, err = io.ReadAll(resp.Body)
+		return err
+	}
+
+	return json.NewDecoder(resp.Body).Decode(receiver)
+}
+
+// restError is the error of an unexpected response.
+type restError struct {
+	method string
+	url    string
+	status int
+	msg    string
+}
+
+func (e *restError) Error() string {
+	return fmt.Sprintf("unexpected response of %s %s: %d %s", e.method, e.url, e.status, e.msg)
+}
+
+func isNotFound(err error) bool {
+	var e *restError
+	return errors.As(err, &e) && e.status == http.StatusNotFound
+}
+
+// listAll requests all the pages of a list API.
+func listAll[T any](c *restClient, path string, query url.Values) ([]T, bool) {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set(c.limitParam, strconv.Itoa(c.pageSize))
+
+	var r []T
+	for page := 1; ; page++ {
+		q.Set("page", strconv.Itoa(page))
+
+		var items []T
+		if !c.do(http.MethodGet, path, q, nil, &items) {
+			return nil, false
+		}
+
+		r = append(r, items...)
+		if len(items) < c.pageSize {
+			return r, true
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/opensourceways/robot-framework-lib/utils"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// A review submitted or dismissed in the web page of the platform is translated into a pull request event
+// whose action is eventActionReview and whose detail is the state of the review. The reviewer is the commenter,
+// or who dismissed the review, and the comment id identifies the review.
+const (
+	eventActionReview = "review"
+
+	reviewStateApproved         = "approved"
+	reviewStateChangesRequested = "changes requested"
+	reviewStateDismissed        = "dismissed"
+)
+
+// The actions which a review can mean, see nativeReviewConfig.
+const (
+	nativeReviewLgtm    = "lgtm"
+	nativeReviewApprove = "approve"
+	nativeReviewBlock   = "block"
+
+	reasonChangesRequested = "changes are requested in a review"
+)
+
+// nativeReviewCommands is the command run for the reviewer, so the review is checked and recorded
+// the same as the command is. The labels added by it are revoked when the review is dismissed.
+var nativeReviewCommands = map[string]string{
+	nativeReviewLgtm:    "/lgtm",
+	nativeReviewApprove: "/approve",
+	nativeReviewBlock:   "/hold " + reasonChangesRequested,
+}
+
+func isNativeReviewEvent(evt *client.GenericEvent) bool {
+	return utils.GetString(evt.Action) == eventActionReview
+}
+
+// handleNativeReview runs the command which the review means, or revokes the labels added by it.
+func (bot *robot) handleNativeReview(repoCnf *repoConfig, evt *client.GenericEvent) error {
+	if utils.GetString(evt.State) != eventStateOpened {
+		return nil
+	}
+
+	org, repo, number := utils.GetString(evt.Org), utils.GetString(evt.Repo), utils.GetString(evt.Number)
+	c := &commandContext{
+		repoCnf:   repoCnf,
+		org:       org,
+		repo:      repo,
+		number:    number,
+		commenter: utils.GetString(evt.Commenter),
+		author:    bot.getPRAuthor(evt),
+		commentID: utils.GetString(evt.CommentID),
+	}
+
+	state := utils.GetString(evt.ActionDetail)
+	if state == reviewStateDismissed {
+		taken := bot.sources.take(prKey(org, repo, number), c.commentID, func(*commandSource) bool {
+			return true
+		})
+		if len(taken) == 0 {
+			// the sources are lost when the robot restarts
+			taken = bot.storedSources(c.org, c.repo, c.number, c.commentID)
+		}
+
+		return bot.revokeLabels(c, taken, "review", "dismissed")
+	}
+
+	if state == reviewStateApproved {
+		// the platform doesn't dismiss the review which is superseded by a later one of the same reviewer
+		if err := bot.releaseReviewHold(c); err != nil {
+			return err
+		}
+	}
+
+	action := repoCnf.NativeReviews.actionOf(state)
+	line, ok := nativeReviewCommands[action]
+	if !ok {
+		return nil
+	}
+
+	if err := bot.handleCommand(c, line); err != nil {
+		return err
+	}
+
+	if action == nativeReviewBlock && sets.New[string](c.addedLabels...).Has(holdLabel) {
+		bot.recordReview(c, reviewActionHold, holdLabel)
+	}
+	return nil
+}
+
+// releaseReviewHold revokes the hold label added by the earlier review of the reviewer which requested changes.
+func (bot *robot) releaseReviewHold(c *commandContext) error {
+	r, ok := bot.store.state(c.org, c.repo, c.number).hold[c.commenter]
+	if !ok {
+		return nil
+	}
+
+	bot.sources.take(prKey(c.org, c.repo, c.number), r.CommentID, func(src *commandSource) bool {
+		return src.command == "hold"
+	})
+
+	src := commandSource{commentID: r.CommentID, user: r.User, command: "hold", labels: []string{holdLabel}}
+	return bot.revokeLabels(c, []commandSource{src}, "review", "superseded")
+}
+
+// storedSources rebuilds the sources of the labels added by the comment or the review from the review records,
+// since the sources are lost when the robot restarts. The approved label is only included if it is
+// the last approval of the pull request.
+func (bot *robot) storedSources(org, repo, number, commentID string) []commandSource {
+	if commentID == "" {
+		return nil
+	}
+
+	st := bot.store.state(org, repo, number)
+
+	var r []commandSource
+	for l, v := range st.lgtm {
+		if v.CommentID == commentID {
+			r = append(r, commandSource{commentID: commentID, user: v.User, command: "lgtm", labels: []string{l}})
+		}
+	}
+	for _, v := range st.approve {
+		if v.CommentID == commentID && len(st.approve) == 1 {
+			r = append(r, commandSource{commentID: commentID, user: v.User, command: "approve",
+				labels: []string{approvedLabel}})
+		}
+	}
+	for _, v := range st.hold {
+		if v.CommentID == commentID {
+			r = append(r, commandSource{commentID: commentID, user: v.User, command: "hold", labels: []string{holdLabel}})
+		}
+	}
+
+	return r
+}
+
+// getPRAuthor returns the author of the pull request, the events of some platforms don't carry it.
+func (bot *robot) getPRAuthor(evt *client.GenericEvent) string {
+	if author := utils.GetString(evt.Author); author != "" {
+		return author
+	}
+
+	info, _ := bot.cli.GetPullRequestInfo(utils.GetString(evt.Org), utils.GetString(evt.Repo), utils.GetString(evt.Number))
+	return info.Author
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	commentReviewState = `@%s, the review state of this pull request is below, the head commit is ***%s***.
+
+| label | user | command | head commit | time |
+| --- | --- | --- | --- | --- |
+%s`
+	commentNoReviewState  = `@%s, there is no review record of this pull request.`
+	commentRebuiltLabels  = `The review labels were rebuilt from the review records by: ***%s***. :wave: %s`
+	commentLabelsUpToDate = `The review labels are consistent with the review records. :wave: `
+	reviewStateTimeLayout = "2006-01-02 15:04:05"
+	shortSHALen           = 8
+)
+
+func init() {
+	commands.register(&command{
+		name: "review-state",
+		role: roleAnyone,
+		help: "Show who added the `lgtm` and `approved` labels at which head commit.",
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.handleReviewState(c)
+		},
+	})
+	commands.register(&command{
+		name: "rebuild-labels",
+		role: roleCollaborator,
+		help: "Rebuild the `lgtm` and `approved` labels from the review records.",
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.handleRebuildLabels(c)
+		},
+	})
+}
+
+// recordReview saves the review action of the commenter.
+func (bot *robot) recordReview(c *commandContext, action, label string) {
+	bot.saveReview(reviewRecord{
+		Org:       c.org,
+		Repo:      c.repo,
+		Number:    c.number,
+		User:      c.commenter,
+		Command:   action,
+		Label:     label,
+		CommentID: c.commentID,
+	})
+}
+
+// saveReview saves the record with the current head commit of the pull request.
+// The failure is only logged, since the labels have been changed.
+func (bot *robot) saveReview(r reviewRecord) {
+	if st := bot.store.state(r.Org, r.Repo, r.Number); !st.changedBy(&r) {
+		return
+	}
+
+	if info, ok := bot.cli.GetPullRequestInfo(r.Org, r.Repo, r.Number); ok {
+		r.HeadSHA = info.HeadSHA
+	}
+	if r.PatchID == "" && (r.Command == reviewActionLgtm || r.Command == reviewActionApprove) {
+		// the reviewed content, it is compared with the updated one
+		r.PatchID, _ = bot.getPatchID(r.Org, r.Repo, r.Number)
+	}
+
+	if err := bot.store.add(r); err != nil {
+		logrus.WithError(err).Errorf("failed to save the review record of %s", prKey(r.Org, r.Repo, r.Number))
+	}
+}
+
+func (bot *robot) handleReviewState(c *commandContext) error {
+	st := bot.store.state(c.org, c.repo, c.number)
+	if len(st.lgtm) == 0 && len(st.approve) == 0 {
+		bot.cli.CreatePRComment(c.org, c.repo, c.number, fmt.Sprintf(commentNoReviewState, c.commenter))
+		return nil
+	}
+
+	head := "unknown"
+	if info, ok := bot.cli.GetPullRequestInfo(c.org, c.repo, c.number); ok && info.HeadSHA != "" {
+		head = shortSHA(info.HeadSHA)
+	}
+
+	var rows []string
+	for _, r := range st.records() {
+		rows = append(rows, fmt.Sprintf("| %s | %s | %s | %s | %s |",
+			r.Label, r.User, r.Command, shortSHA(r.HeadSHA), r.Time.Local().Format(reviewStateTimeLayout)))
+	}
+
+	if ok := bot.cli.CreatePRComment(c.org, c.repo, c.number,
+		fmt.Sprintf(commentReviewState, c.commenter, head, strings.Join(rows, "\n"))); !ok {
+		return fmt.Errorf("failed to comment on pull request")
+	}
+	return nil
+}
+
+func (bot *robot) handleRebuildLabels(c *commandContext) error {
+	logrus.Infof("handleRebuildLabels, commenter: %s, org: %s, repo: %s, number: %s", c.commenter, c.org, c.repo, c.number)
+	added, removed, err := bot.rebuildReviewLabels(c.org, c.repo, c.number)
+	if err != nil {
+		return err
+	}
+
+	if len(added) == 0 && len(removed) == 0 {
+		bot.cli.CreatePRComment(c.org, c.repo, c.number, commentLabelsUpToDate)
+		return nil
+	}
+
+	var detail []string
+	if len(added) > 0 {
+		detail = append(detail, fmt.Sprintf("\nAdded: ***%s***", strings.Join(added, ", ")))
+	}
+	if len(removed) > 0 {
+		detail = append(detail, fmt.Sprintf("\nRemoved: ***%s***", strings.Join(removed, ", ")))
+	}
+	bot.cli.CreatePRComment(c.org, c.repo, c.number,
+		fmt.Sprintf(commentRebuiltLabels, c.commenter, strings.Join(detail, "")))
+	return nil
+}
+
+// rebuildReviewLabels makes the lgtm and approved labels of the pull request the same as the review state.
+func (bot *robot) rebuildReviewLabels(org, repo, number string) (added, removed []string, err error) {
+	st := bot.store.state(org, repo, number)
+	want := sets.New[string](st.labels()...)
+
+	labels := bot.getPRLabelSet(org, repo, number)
+	have := sets.New[string](getLGTMLabelsOnPR(labels)...)
+	if labels.Has(approvedLabel) {
+		have.Insert(approvedLabel)
+	}
+
+	if removed = sets.List(have.Difference(want)); len(removed) > 0 {
+		if ok := bot.cli.RemovePRLabels(org, repo, number, removed); !ok {
+			return nil, nil, fmt.Errorf("failed to remove label on pull request")
+		}
+	}
+
+	if added = sets.List(want.Difference(have)); len(added) > 0 {
+		if ok := bot.cli.AddPRLabels(org, repo, number, added); !ok {
+			return nil, removed, fmt.Errorf("failed to add label on pull request")
+		}
+	}
+
+	return added, removed, nil
+}
+
+// records returns the records which make up the state, sorted by time.
+func (s *reviewState) records() []reviewRecord {
+	r := make([]reviewRecord, 0, len(s.lgtm)+len(s.approve))
+	for _, v := range s.lgtm {
+		r = append(r, v)
+	}
+	for _, v := range s.approve {
+		r = append(r, v)
+	}
+
+	sort.Slice(r, func(i, j int) bool {
+		return r[i].Time.Before(r[j].Time)
+	})
+
+	return r
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > shortSHALen {
+		return sha[:shortSHALen]
+	}
+
+	return sha
+}
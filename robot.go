@@ -15,6 +15,7 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/opensourceways/robot-framework-lib/client"
@@ -42,22 +43,89 @@ type iClient interface {
 	MergePullRequest(org, repo, number, mergeMethod string) (success bool)
 	CheckIfPRReopenEvent(evt *client.GenericEvent) (yes bool)
 	CheckIfPRLabelsUpdateEvent(evt *client.GenericEvent) (yes bool)
+	// CheckIfPRClosedEvent checks whether the event is that a pull request is closed without being merged
+	CheckIfPRClosedEvent(evt *client.GenericEvent) (yes bool)
+	// CheckIfPRMergedEvent checks whether the event is that a pull request is merged
+	CheckIfPRMergedEvent(evt *client.GenericEvent) (yes bool)
 	ListPullRequestOperationLogs(org, repo, number string) (result []client.PullRequestOperationLog, success bool)
+	GetPullRequestChanges(org, repo, number string) (result []client.CommitFile, success bool)
+	// AssignPullRequest assigns the users to a pull request
+	AssignPullRequest(org, repo, number string, logins []string) (success bool)
+	// UnassignPullRequest removes the users from the assignees of a pull request
+	UnassignPullRequest(org, repo, number string, logins []string) (success bool)
+	// RequestPullRequestReviewers requests the users to review a pull request
+	RequestPullRequestReviewers(org, repo, number string, logins []string) (success bool)
+	// ClosePullRequest closes a pull request
+	ClosePullRequest(org, repo, number string) (success bool)
+	// ReopenPullRequest reopens a closed pull request
+	ReopenPullRequest(org, repo, number string) (success bool)
+	// GetPullRequestInfo gets the title, the draft state and the head commit of a pull request
+	GetPullRequestInfo(org, repo, number string) (result pullRequestInfo, success bool)
+	// UpdatePullRequestTitle changes the title of a pull request
+	UpdatePullRequestTitle(org, repo, number, title string) (success bool)
+	// ListOpenPullRequests lists all the open pull requests of a repository
+	ListOpenPullRequests(org, repo string) (result []pullRequestInfo, success bool)
+	// AddCommentReaction reacts to a comment of a pull request, such as '+1'
+	AddCommentReaction(org, repo, number, commentID, reaction string) (success bool)
+	// GetCurrentUser gets the login of the user behind the token
+	GetCurrentUser() (login string, success bool)
 }
 
 type robot struct {
 	cli iClient
-	cnf *configuration
-	log *logrus.Entry
+	// caps is what the platform supports
+	caps    *capabilities
+	cnf     *configuration
+	log     *logrus.Entry
+	sources *commandSources
+	store   *reviewStore
+	// deliveries dedupes the webhook deliveries retried by the platform
+	deliveries *deliveryCache
+	// events serializes the events of the same pull request
+	events *prDispatcher
+	// login returns the user behind the token of the organization
+	login func(org string) string
 }
 
 func (bot *robot) GetConfigmap() config.Configmap {
 	return bot.cnf
 }
 
-func newRobot(c *configuration, token []byte) *robot {
+func newRobot(c *configuration, tokens *tokenSet, opt *robotOptions, store *reviewStore) (*robot, error) {
 	logger := framework.NewLogger().WithField("component", component)
-	return &robot{cli: client.NewClient(token, logger), cnf: c, log: logger}
+	p, ok := platforms[opt.platform]
+	if !ok {
+		return nil, fmt.Errorf("unknown platform: %s, it should be one of %s", opt.platform, platformNames())
+	}
+
+	cli, err := newOrgClients(&p, tokens, opt.apiURL, logger)
+	if err != nil {
+		return nil, err
+	}
+	if err := cli.validate(c); err != nil {
+		return nil, err
+	}
+
+	return &robot{
+		cli:        cli,
+		caps:       &p.caps,
+		cnf:        c,
+		log:        logger,
+		sources:    newCommandSources(),
+		store:      store,
+		deliveries: newDeliveryCache(opt.deliveryTTL, opt.deliveryCapacity),
+		events:     newPRDispatcher(opt.eventWorkers),
+		login:      cli.login,
+	}, nil
+}
+
+// legalOperator returns who can add or remove the labels legally.
+func (bot *robot) legalOperator(repoCnf *repoConfig, org string) string {
+	if repoCnf.LegalOperator != "" {
+		return repoCnf.LegalOperator
+	}
+
+	return bot.login(org)
 }
 
 func (bot *robot) NewConfig() config.Configmap {
@@ -65,8 +133,8 @@ func (bot *robot) NewConfig() config.Configmap {
 }
 
 func (bot *robot) RegisterEventHandler(p framework.HandlerRegister) {
-	p.RegisterPullRequestHandler(bot.handlePREvent)
-	p.RegisterPullRequestCommentHandler(bot.handlePullRequestCommentEvent)
+	p.RegisterPullRequestHandler(bot.events.wrap(bot.handlePREvent))
+	p.RegisterPullRequestCommentHandler(bot.events.wrap(bot.handlePullRequestCommentEvent))
 }
 
 func (bot *robot) GetLogger() *logrus.Entry {
@@ -74,17 +142,27 @@ func (bot *robot) GetLogger() *logrus.Entry {
 }
 
 // getConfig first checks if the specified organization and repository is available in the provided repoConfig list.
-// Returns an error if not found the available repoConfig.
+// Returns an error if not found the available repoConfig, or there is no token for the organization,
+// which may be added to the configuration after the startup.
 func (bot *robot) getConfig(cnf config.Configmap, org, repo string) (*repoConfig, error) {
 	c := cnf.(*configuration)
-	if bc := c.get(org, repo); bc != nil {
-		return bc, nil
+	bc := c.get(org, repo)
+	if bc == nil {
+		return nil, errors.New("no config for this repo: " + org + "/" + repo)
+	}
+	if bot.login(org) == "" {
+		return nil, errors.New("no token for the organization: " + org)
 	}
 
-	return nil, errors.New("no config for this repo: " + org + "/" + repo)
+	return bc, nil
 }
 
 func (bot *robot) handlePREvent(evt *client.GenericEvent, cnf config.Configmap, logger *logrus.Entry) {
+	if bot.deliveries.seen(evt) {
+		logger.Info("skip the duplicate delivery")
+		return
+	}
+
 	org, repo, number := utils.GetString(evt.Org), utils.GetString(evt.Repo), utils.GetString(evt.Number)
 	repoCnf, err := bot.getConfig(cnf, org, repo)
 	// If the specified repository not match any repository  in the repoConfig list, it logs the error and returns
@@ -93,50 +171,98 @@ func (bot *robot) handlePREvent(evt *client.GenericEvent, cnf config.Configmap,
 		return
 	}
 
+	if isNativeReviewEvent(evt) {
+		if err := bot.handleNativeReview(repoCnf, evt); err != nil {
+			logger.WithError(err).Warning()
+		}
+		return
+	}
+	if merged := bot.cli.CheckIfPRMergedEvent(evt); merged || bot.cli.CheckIfPRClosedEvent(evt) {
+		if err := bot.handlePRClosed(repoCnf, org, repo, number, merged); err != nil {
+			logger.WithError(err).Warning()
+		}
+		return
+	}
+	if bot.cli.CheckIfPRCreateEvent(evt) {
+		// the reviewed content is compared with it when the source code is updated
+		bot.savePatchID(org, repo, number)
+	}
 	if bot.cli.CheckIfPRReopenEvent(evt) || bot.cli.CheckIfPRSourceCodeUpdateEvent(evt) {
+		// the pull request becomes active again
+		bot.removeLifecycleLabels(org, repo, number)
 		if err := bot.clearLabel(evt, org, repo, number); err != nil {
 			logger.WithError(err).Warning()
 			return
 		}
 	}
 	if bot.cli.CheckIfPRLabelsUpdateEvent(evt) {
+		if err := bot.guardReviewLabels(repoCnf, org, repo, number); err != nil {
+			logger.WithError(err).Warning()
+		}
 		if err := bot.handleMerge(repoCnf, org, repo, number); err != nil {
 			logger.WithError(err).Warning()
 			return
 		}
+	} else if utils.GetString(evt.State) == "opened" {
+		// the title or the draft state may be changed
+		if err := bot.handleWIP(repoCnf, org, repo, number); err != nil {
+			logger.WithError(err).Warning()
+			return
+		}
 	}
 }
 
 func (bot *robot) handlePullRequestCommentEvent(evt *client.GenericEvent, cnf config.Configmap, logger *logrus.Entry) {
+	if bot.deliveries.seen(evt) {
+		logger.Info("skip the duplicate delivery")
+		return
+	}
+
 	org, repo, number := utils.GetString(evt.Org), utils.GetString(evt.Repo), utils.GetString(evt.Number)
-	comment, commenter, author := utils.GetString(evt.Comment), utils.GetString(evt.Commenter), utils.GetString(evt.Author)
+	comment, commenter := utils.GetString(evt.Comment), utils.GetString(evt.Commenter)
 	repoCnf, err := bot.getConfig(cnf, org, repo)
 	// If the specified repository not match any repository  in the repoConfig list, it logs the error and returns
 	if err != nil {
 		logger.WithError(err).Warning()
 		return
 	}
-	lines := strings.Split(comment, "\n")
-	for _, line := range lines {
-		if err := bot.handleRebase(line, commenter, org, repo, number); err != nil {
-			logger.WithError(err).Warning()
-		}
+	c := &commandContext{
+		repoCnf:   repoCnf,
+		org:       org,
+		repo:      repo,
+		number:    number,
+		commenter: commenter,
+		author:    bot.getPRAuthor(evt),
+		commentID: utils.GetString(evt.CommentID),
+	}
 
-		if err := bot.handledSquash(line, commenter, org, repo, number); err != nil {
+	switch getNoteAction(evt) {
+	case noteActionUpdate:
+		if err := bot.handleCommentEdited(c, comment); err != nil {
 			logger.WithError(err).Warning()
 		}
-
-		if err := bot.handleLGTM(repoCnf, line, commenter, author, org, repo, number); err != nil {
+		return
+	case noteActionDelete:
+		if err := bot.handleCommentDeleted(c); err != nil {
 			logger.WithError(err).Warning()
 		}
+		return
+	}
 
-		if err := bot.handleApprove(repoCnf, line, commenter, author, org, repo, number); err != nil {
-			logger.WithError(err).Warning()
-		}
+	if repoCnf.Lifecycle.enabled() && commenter != bot.login(org) {
+		// the conversation makes the pull request active again
+		bot.removeLifecycleLabels(org, repo, number)
+	}
 
-		if err := bot.handleCheckPR(repoCnf, line, commenter, org, repo, number); err != nil {
+	lines := strings.Split(comment, "\n")
+	for _, line := range lines {
+		if err := bot.handleCommand(c, line); err != nil {
 			logger.WithError(err).Warning()
 		}
 	}
 
+	if c.handled && bot.caps.reactions {
+		// some commands change nothing and say nothing, such as a replayed one
+		bot.cli.AddCommentReaction(org, repo, number, c.commentID, reactionAck)
+	}
 }
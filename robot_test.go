@@ -0,0 +1,296 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/opensourceways/server-common-lib/config"
+)
+
+const (
+	testOrg  = "org"
+	testRepo = "repo"
+
+	labelCIPassed = "ci-pipeline-success"
+)
+
+func testConfig() *configuration {
+	return &configuration{ConfigItems: []repoConfig{{
+		RepoFilter:             config.RepoFilter{Repos: []string{testOrg + "/" + testRepo}},
+		LabelsForMerge:         []string{labelCIPassed},
+		LabelPrefixesForAnyone: []string{"priority/"},
+		LabelsForMaintainers:   []string{"priority/critical"},
+	}}}
+}
+
+func TestReviewFlows(t *testing.T) {
+	cases := []struct {
+		name   string
+		config func(cnf *repoConfig)
+		flow   func(s *scenario) *scenario
+	}{
+		{
+			name: "lgtm, approve and the label for merging",
+			flow: func(s *scenario) *scenario {
+				return s.
+					comment("alice", "/lgtm").
+					expectLabels(lgtmLabel).
+					comment("alice", "/approve").
+					expectLabels(lgtmLabel, approvedLabel).
+					expectState(fakePRStateOpen).
+					label(scenarioRobot, labelCIPassed).
+					expectMerged()
+			},
+		},
+		{
+			name: "the label for merging added illegally",
+			flow: func(s *scenario) *scenario {
+				return s.
+					comment("alice", "/lgtm").
+					comment("alice", "/approve").
+					label("alice", labelCIPassed).
+					expectNoLabels(labelCIPassed).
+					expectState(fakePRStateOpen)
+			},
+		},
+		{
+			name: "the label for merging added by a trusted operator",
+			config: func(cnf *repoConfig) {
+				cnf.TrustedOperators = []string{"ci-bot"}
+			},
+			flow: func(s *scenario) *scenario {
+				return s.
+					label("ci-bot", labelCIPassed).
+					expectLabels(labelCIPassed).
+					after(time.Minute).
+					label("ci-bot", approvedLabel).
+					expectNoLabels(approvedLabel).
+					expectLabels(labelCIPassed).
+					comment("alice", "/lgtm").
+					comment("alice", "/approve").
+					expectMerged()
+			},
+		},
+		{
+			name: "reopen by the command",
+			flow: func(s *scenario) *scenario {
+				return s.
+					comment("alice", "/lgtm").
+					close("alice", false).
+					comment("alice", "/reopen").
+					expectState(fakePRStateOpen).
+					expectNoLabels(lgtmLabel).
+					expectCommentCount("When PR is reopened", 1)
+			},
+		},
+		{
+			name: "stale pull request commented",
+			config: func(cnf *repoConfig) {
+				cnf.Lifecycle = lifecycleConfig{StaleDays: 30, RottenDays: 30, CloseDays: 7}
+			},
+			flow: func(s *scenario) *scenario {
+				return s.
+					after(31*day).
+					runLifecycle().
+					expectLabels(staleLabel).
+					comment("carol", "is it still needed?").
+					expectNoLabels(staleLabel)
+			},
+		},
+		{
+			name: "work in progress",
+			flow: func(s *scenario) *scenario {
+				return s.
+					retitle("WIP: fix the crash", false).
+					expectLabels(wipLabel).
+					comment("alice", "/lgtm").
+					comment("alice", "/approve").
+					label(scenarioRobot, labelCIPassed).
+					expectState(fakePRStateOpen).
+					retitle("fix the crash", true).
+					expectLabels(wipLabel).
+					expectState(fakePRStateOpen).
+					retitle("fix the crash", false).
+					expectNoLabels(wipLabel).
+					expectMerged()
+			},
+		},
+		{
+			name: "work-in-progress label removed by hand",
+			flow: func(s *scenario) *scenario {
+				return s.
+					retitle("WIP: fix the crash", false).
+					comment("alice", "/lgtm").
+					comment("alice", "/approve").
+					label(scenarioRobot, labelCIPassed).
+					unlabel("alice", wipLabel).
+					expectLabels(wipLabel).
+					expectState(fakePRStateOpen)
+			},
+		},
+		{
+			name: "hold",
+			flow: func(s *scenario) *scenario {
+				return s.
+					comment("alice", "/hold wait for the release").
+					expectLabels(holdLabel).
+					comment("alice", "/hold").
+					expectCommentCount(holdLabel+"*** was added", 1).
+					comment("alice", "/lgtm").
+					comment("alice", "/approve").
+					label(scenarioRobot, labelCIPassed).
+					expectState(fakePRStateOpen).
+					comment("alice", "/unhold").
+					expectNoLabels(holdLabel).
+					expectMerged()
+			},
+		},
+		{
+			name: "revoke with the edited comment",
+			flow: func(s *scenario) *scenario {
+				return s.
+					comment("alice", "/lgtm\n/hold").
+					expectLabels(lgtmLabel, holdLabel).
+					editComment("/hold").
+					expectNoLabels(lgtmLabel).
+					expectLabels(holdLabel).
+					expectComment("because the comment which added it was edited by").
+					deleteComment().
+					expectNoLabels(holdLabel)
+			},
+		},
+		{
+			name: "revoke with the edited comment after restart",
+			flow: func(s *scenario) *scenario {
+				return s.
+					comment("alice", "/lgtm\n/approve").
+					expectLabels(lgtmLabel, approvedLabel).
+					restart().
+					editComment("/approve").
+					expectNoLabels(lgtmLabel).
+					expectLabels(approvedLabel).
+					restart().
+					deleteComment().
+					expectNoLabels(approvedLabel)
+			},
+		},
+		{
+			name: "labels for maintainers matching the prefixes for anyone",
+			flow: func(s *scenario) *scenario {
+				return s.
+					comment("bob", "/label priority/low priority/critical").
+					expectLabels("priority/low").
+					expectNoLabels("priority/critical").
+					expectComment(fmt.Sprintf(reasonLabelMaintainers, "priority/critical")).
+					comment("alice", "/label priority/critical").
+					expectLabels("priority/critical")
+			},
+		},
+		{
+			name: "protected labels in another case",
+			config: func(cnf *repoConfig) {
+				cnf.LabelsForMaintainers = []string{"LGTM", "Approved", "CI-Pipeline-Success"}
+			},
+			flow: func(s *scenario) *scenario {
+				return s.
+					comment("alice", "/label LGTM Approved CI-Pipeline-Success").
+					expectNoLabels("LGTM", "Approved", "CI-Pipeline-Success").
+					expectComment(fmt.Sprintf(reasonLabelProtected, "LGTM"))
+			},
+		},
+		{
+			name: "lgtm by the author",
+			flow: func(s *scenario) *scenario {
+				return s.
+					comment("bob", "/lgtm").
+					expectNoLabels(lgtmLabel).
+					expectComment(commentAddLGTMBySelf)
+			},
+		},
+		{
+			name: "alias disabled",
+			config: func(cnf *repoConfig) {
+				cnf.DisabledCommands = []string{"rebase"}
+			},
+			flow: func(s *scenario) *scenario {
+				return s.
+					comment("alice", "/rebase").
+					expectNoLabels(mergeMethodLabelPrefix+mergeMethodRebase).
+					comment("alice", "/merge-method squash").
+					expectLabels(mergeMethodLabelPrefix+mergeMethodSquash).
+					comment("alice", "/merge-method merge").
+					expectLabels(mergeMethodLabelPrefix + mergeMethodMerge).
+					expectNoLabels(mergeMethodLabelPrefix + mergeMethodSquash)
+			},
+		},
+		{
+			name: "review dismissed after restart",
+			config: func(cnf *repoConfig) {
+				cnf.NativeReviews = nativeReviewConfig{Approved: nativeReviewApprove}
+			},
+			flow: func(s *scenario) *scenario {
+				return s.
+					review("alice", reviewStateApproved).
+					expectLabels(approvedLabel).
+					restart().
+					dismissReview("carol").
+					expectNoLabels(approvedLabel).
+					expectComment("because the review which added it was dismissed by")
+			},
+		},
+		{
+			name: "changes requested superseded by an approval",
+			config: func(cnf *repoConfig) {
+				cnf.NativeReviews = nativeReviewConfig{Approved: nativeReviewLgtm, ChangesRequested: nativeReviewBlock}
+			},
+			flow: func(s *scenario) *scenario {
+				return s.
+					review("alice", reviewStateChangesRequested).
+					expectLabels(holdLabel).
+					restart().
+					review("alice", reviewStateApproved).
+					expectNoLabels(holdLabel).
+					expectLabels(lgtmLabel).
+					expectComment("because the review which added it was superseded by")
+			},
+		},
+		{
+			name: "lgtm label truncated by the earlier versions",
+			config: func(cnf *repoConfig) {
+				cnf.LgtmCountsRequired = 2
+			},
+			flow: func(s *scenario) *scenario {
+				caps := fakeCapabilities
+				caps.labelLenLimit = 12
+
+				return s.
+					withCapabilities(caps).
+					collaborators("alice-the-reviewer").
+					label(scenarioRobot, "lgtm-alice-t").
+					comment("alice-the-reviewer", "/lgtm").
+					expectLabels("lgtm-alice-t").
+					expectNoLabels(genLGTMLabel(&caps, "alice-the-reviewer", 2)).
+					comment("alice-the-reviewer", "/lgtm cancel").
+					expectNoLabels("lgtm-alice-t")
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cnf := testConfig()
+			if c.config != nil {
+				c.config(&cnf.ConfigItems[0])
+			}
+
+			s := newScenario(cnf, testOrg, testRepo).
+				collaborators("alice").
+				open("bob", "fix the crash")
+
+			if err := c.flow(s).err(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/opensourceways/robot-framework-lib/framework"
+	"github.com/opensourceways/robot-framework-lib/utils"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// scenarioRobot is the user of the robot if the legal operator is not configured
+	scenarioRobot = "robot"
+	// scenarioMaxRounds limits the rounds of handling the events caused by the robot itself
+	scenarioMaxRounds = 50
+)
+
+// scenario scripts a flow of a pull request on the fake forge, and checks the results. Each step handles
+// all the events it causes, including the ones caused by the robot, such as the labels added by '/lgtm'.
+// The steps after a failed one are skipped, for example:
+//
+//	err := newScenario(cnf, "org", "repo").
+//		collaborators("alice").
+//		open("bob", "fix the crash").
+//		comment("alice", "/lgtm").
+//		comment("alice", "/approve").
+//		expectLabels(lgtmLabel, approvedLabel).
+//		expectMerged().
+//		err()
+type scenario struct {
+	forge *fakeForge
+	bot   *robot
+	cnf   *configuration
+
+	org, repo, number string
+	// commentID is the id of the last comment
+	commentID string
+	// reviewID is the id of the last review
+	reviewID string
+	failures []string
+}
+
+// newScenario creates a scenario of the repository, the robot is the legal operator of it and a collaborator.
+func newScenario(cnf *configuration, org, repo string) *scenario {
+	s := &scenario{cnf: cnf, org: org, repo: repo}
+	if err := cnf.Validate(); err != nil {
+		return s.fail("invalid configuration: %v", err)
+	}
+	repoCnf := cnf.get(org, repo)
+	if repoCnf == nil {
+		return s.fail("no config for this repo: %s/%s", org, repo)
+	}
+
+	user := repoCnf.LegalOperator
+	if user == "" {
+		user = scenarioRobot
+	}
+	s.forge = newFakeForge(user)
+	s.forge.addCollaborators(org, repo, user)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	s.bot = &robot{
+		cli:        s.forge,
+		caps:       &fakeCapabilities,
+		cnf:        cnf,
+		log:        logrus.NewEntry(logger),
+		sources:    newCommandSources(),
+		store:      &reviewStore{records: map[string][]reviewRecord{}},
+		deliveries: newDeliveryCache(0, 0),
+		events:     newPRDispatcher(1),
+		login: func(string) string {
+			return user
+		},
+	}
+
+	return s
+}
+
+// withCapabilities makes the robot work as on a platform which supports the capabilities.
+func (s *scenario) withCapabilities(caps capabilities) *scenario {
+	if s.bot != nil {
+		s.bot.caps = &caps
+	}
+
+	return s
+}
+
+func (s *scenario) fail(format string, args ...any) *scenario {
+	s.failures = append(s.failures, fmt.Sprintf(format, args...))
+	return s
+}
+
+// step runs the action if no step failed, and handles the events caused by it.
+func (s *scenario) step(action func()) *scenario {
+	if len(s.failures) > 0 {
+		return s
+	}
+
+	action()
+	s.settle()
+
+	return s
+}
+
+// settle handles the queued events until no more events are caused.
+func (s *scenario) settle() {
+	for i := 0; i < scenarioMaxRounds; i++ {
+		events := s.forge.takeEvents()
+		if len(events) == 0 {
+			return
+		}
+
+		for _, evt := range events {
+			s.dispatch(evt)
+		}
+	}
+
+	s.fail("the events are not settled after %d rounds", scenarioMaxRounds)
+}
+
+func (s *scenario) dispatch(evt *client.GenericEvent) {
+	key := prKey(utils.GetString(evt.Org), utils.GetString(evt.Repo), utils.GetString(evt.Number))
+	logger := s.bot.log.WithField("event", utils.GetString(evt.EventGUID))
+
+	s.bot.events.run(key, logger, func() {
+		switch utils.GetString(evt.EventType) {
+		case framework.PullRequestEvent:
+			s.bot.handlePREvent(evt, s.cnf, logger)
+		case framework.NoteEvent:
+			s.bot.handlePullRequestCommentEvent(evt, s.cnf, logger)
+		}
+	})
+}
+
+func (s *scenario) needPR() bool {
+	if s.number == "" {
+		s.fail("no pull request is opened")
+		return false
+	}
+
+	return true
+}
+
+// collaborators grants the users the permission to write the repository.
+func (s *scenario) collaborators(users ...string) *scenario {
+	return s.step(func() {
+		s.forge.addCollaborators(s.org, s.repo, users...)
+	})
+}
+
+// open opens a pull request, which is the one the following steps work on.
+func (s *scenario) open(author, title string) *scenario {
+	return s.step(func() {
+		s.number = s.forge.openPR(s.org, s.repo, author, title)
+	})
+}
+
+// push pushes the commits which change the files to the pull request.
+func (s *scenario) push(files []client.CommitFile, commits ...client.PRCommit) *scenario {
+	return s.step(func() {
+		if s.needPR() {
+			s.forge.pushCommits(s.org, s.repo, s.number, files, commits...)
+		}
+	})
+}
+
+// retitle changes the title and the draft state of the pull request.
+func (s *scenario) retitle(title string, draft bool) *scenario {
+	return s.step(func() {
+		if s.needPR() {
+			s.forge.setTitle(s.org, s.repo, s.number, title, draft)
+		}
+	})
+}
+
+// comment posts a comment by the user.
+func (s *scenario) comment(user, body string) *scenario {
+	return s.step(func() {
+		if s.needPR() {
+			s.commentID = s.forge.comment(s.org, s.repo, s.number, user, body)
+		}
+	})
+}
+
+// editComment changes the last comment posted by comment.
+func (s *scenario) editComment(body string) *scenario {
+	return s.step(func() {
+		if s.needPR() {
+			s.forge.editComment(s.org, s.repo, s.number, s.commentID, body)
+		}
+	})
+}
+
+// deleteComment deletes the last comment posted by comment.
+func (s *scenario) deleteComment() *scenario {
+	return s.editComment("")
+}
+
+// review submits a review in the state by the user in the web page, such as reviewStateApproved.
+func (s *scenario) review(user, state string) *scenario {
+	return s.step(func() {
+		if s.needPR() {
+			s.reviewID = s.forge.review(s.org, s.repo, s.number, user, state, "")
+		}
+	})
+}
+
+// dismissReview dismisses the last review submitted by review.
+func (s *scenario) dismissReview(user string) *scenario {
+	return s.step(func() {
+		if s.needPR() {
+			s.forge.review(s.org, s.repo, s.number, user, reviewStateDismissed, s.reviewID)
+		}
+	})
+}
+
+// restart makes the robot lose what it keeps in memory, the review records are kept.
+func (s *scenario) restart() *scenario {
+	return s.step(func() {
+		s.bot.sources = newCommandSources()
+	})
+}
+
+// label adds the labels by the user in the web page instead of the commands.
+func (s *scenario) label(user string, labels ...string) *scenario {
+	return s.step(func() {
+		if s.needPR() {
+			s.forge.changeLabels(s.org, s.repo, s.number, user, labels, true)
+		}
+	})
+}
+
+// unlabel removes the labels by the user in the web page instead of the commands.
+func (s *scenario) unlabel(user string, labels ...string) *scenario {
+	return s.step(func() {
+		if s.needPR() {
+			s.forge.changeLabels(s.org, s.repo, s.number, user, labels, false)
+		}
+	})
+}
+
+// close closes the pull request by the user, or merges it if merge is true.
+func (s *scenario) close(user string, merge bool) *scenario {
+	return s.step(func() {
+		if s.needPR() {
+			s.forge.close(s.org, s.repo, s.number, user, merge)
+		}
+	})
+}
+
+// after makes the time pass for the pull request.
+func (s *scenario) after(d time.Duration) *scenario {
+	return s.step(func() {
+		if s.needPR() {
+			s.forge.age(s.org, s.repo, s.number, d)
+		}
+	})
+}
+
+// reconcile runs a round of the reconciler on the repository.
+func (s *scenario) reconcile() *scenario {
+	return s.step(func() {
+		if _, err := s.bot.reconcileRepo(s.cnf.get(s.org, s.repo), s.org, s.repo, s.bot.log); err != nil {
+			s.fail("reconcile: %v", err)
+		}
+	})
+}
+
+// runLifecycle runs a round of checking the inactive pull requests.
+func (s *scenario) runLifecycle() *scenario {
+	return s.step(s.bot.runLifecycle)
+}
+
+// expect checks the pull request if no step failed.
+func (s *scenario) expect(check func(pr *fakePullRequest)) *scenario {
+	if len(s.failures) > 0 || !s.needPR() {
+		return s
+	}
+
+	pr, ok := s.forge.pullRequest(s.org, s.repo, s.number)
+	if !ok {
+		return s.fail("the pull request %s is missing", s.number)
+	}
+	check(&pr)
+
+	return s
+}
+
+// expectLabels checks that the pull request has all the labels.
+func (s *scenario) expectLabels(labels ...string) *scenario {
+	return s.expect(func(pr *fakePullRequest) {
+		if v := sets.New[string](labels...).Difference(pr.labels); v.Len() > 0 {
+			s.fail("missing labels: %s, the labels are: %s",
+				strings.Join(sets.List(v), ", "), strings.Join(sets.List(pr.labels), ", "))
+		}
+	})
+}
+
+// expectNoLabels checks that the pull request has none of the labels.
+func (s *scenario) expectNoLabels(labels ...string) *scenario {
+	return s.expect(func(pr *fakePullRequest) {
+		if v := sets.New[string](labels...).Intersection(pr.labels); v.Len() > 0 {
+			s.fail("unexpected labels: %s", strings.Join(sets.List(v), ", "))
+		}
+	})
+}
+
+// expectState checks the state of the pull request, which is one of open, closed and merged.
+func (s *scenario) expectState(state string) *scenario {
+	return s.expect(func(pr *fakePullRequest) {
+		if pr.state != state {
+			s.fail("the state is %s, not %s", pr.state, state)
+		}
+	})
+}
+
+// expectMerged checks that the pull request is merged by the robot.
+func (s *scenario) expectMerged() *scenario {
+	return s.expect(func(pr *fakePullRequest) {
+		if pr.state != fakePRStateMerged || pr.mergedBy != s.forge.robot {
+			s.fail("the pull request is not merged by the robot, the state is %s", pr.state)
+		}
+	})
+}
+
+// expectComment checks that the robot has commented something containing the text.
+func (s *scenario) expectComment(text string) *scenario {
+	return s.expect(func(pr *fakePullRequest) {
+		for i := range pr.comments {
+			if c := &pr.comments[i]; c.User == s.forge.robot && strings.Contains(c.Body, text) {
+				return
+			}
+		}
+		s.fail("no comment of the robot contains: %s", text)
+	})
+}
+
+// expectCommentCount checks that the robot made n comments containing the text.
+func (s *scenario) expectCommentCount(text string, n int) *scenario {
+	return s.expect(func(pr *fakePullRequest) {
+		count := 0
+		for i := range pr.comments {
+			if c := &pr.comments[i]; c.User == s.forge.robot && strings.Contains(c.Body, text) {
+				count++
+			}
+		}
+		if count != n {
+			s.fail("%d comments of the robot contain: %s, want %d", count, text, n)
+		}
+	})
+}
+
+// expectReaction checks that the robot has reacted to the last comment posted by comment.
+func (s *scenario) expectReaction(reaction string) *scenario {
+	return s.expect(func(pr *fakePullRequest) {
+		for i := range pr.comments {
+			if c := &pr.comments[i]; c.ID == s.commentID {
+				if !sets.New[string](c.Reactions...).Has(reaction) {
+					s.fail("the last comment is not reacted to by %s", reaction)
+				}
+				return
+			}
+		}
+		s.fail("the last comment is missing")
+	})
+}
+
+// err returns the failures of the scenario.
+func (s *scenario) err() error {
+	if len(s.failures) == 0 {
+		return nil
+	}
+
+	return errors.New(strings.Join(s.failures, "\n"))
+}
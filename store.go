@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	reviewActionLgtm          = "lgtm"
+	reviewActionLgtmCancel    = "lgtm cancel"
+	reviewActionApprove       = "approve"
+	reviewActionApproveCancel = "approve cancel"
+	// reviewActionHold is the hold label added by a review which requests changes, it is released by
+	// reviewActionUnhold when the review is dismissed or superseded.
+	reviewActionHold   = "hold"
+	reviewActionUnhold = "unhold"
+	// reviewActionDrop means the records of the pull request are dropped, it is never kept in memory.
+	reviewActionDrop = "drop"
+	// reviewActionClear means all the review records before it are obsolete, such as the source code is updated.
+	reviewActionClear = "clear"
+)
+
+// reviewRecord is a review action on a pull request.
+type reviewRecord struct {
+	Org     string `json:"org"`
+	Repo    string `json:"repo"`
+	Number  string `json:"number"`
+	User    string `json:"user"`
+	Command string `json:"command"`
+	// Label is the label added or removed by the action, an empty one means all the labels of the command.
+	Label     string `json:"label,omitempty"`
+	HeadSHA   string `json:"head_sha,omitempty"`
+	CommentID string `json:"comment_id,omitempty"`
+	// PatchID is the id of the change content of the pull request.
+	PatchID string    `json:"patch_id,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// reviewState is the current review state of a pull request which is replayed from the records.
+type reviewState struct {
+	// lgtm is the latest lgtm record of each lgtm label
+	lgtm map[string]reviewRecord
+	// approve is the latest approve record of each approver
+	approve map[string]reviewRecord
+	// hold is the latest hold record of each reviewer whose review requests changes
+	hold map[string]reviewRecord
+}
+
+// labels returns the review labels which the pull request should have.
+func (s *reviewState) labels() []string {
+	r := make([]string, 0, len(s.lgtm)+1)
+	for l := range s.lgtm {
+		r = append(r, l)
+	}
+	sort.Strings(r)
+
+	if len(s.approve) > 0 {
+		r = append(r, approvedLabel)
+	}
+
+	return r
+}
+
+// changedBy checks whether the record changes the state, so that a replayed action isn't recorded again.
+func (s *reviewState) changedBy(r *reviewRecord) bool {
+	switch r.Command {
+	case reviewActionLgtm:
+		v, ok := s.lgtm[r.Label]
+		return !ok || v.User != r.User
+	case reviewActionLgtmCancel:
+		if r.Label == "" {
+			return len(s.lgtm) > 0
+		}
+		_, ok := s.lgtm[r.Label]
+		return ok
+	case reviewActionApprove:
+		_, ok := s.approve[r.User]
+		return !ok
+	case reviewActionApproveCancel:
+		return len(s.approve) > 0
+	case reviewActionClear:
+		return len(s.lgtm) > 0 || len(s.approve) > 0
+	case reviewActionUnhold:
+		_, ok := s.hold[r.User]
+		return ok
+	}
+
+	return true
+}
+
+// reviewStore is an embedded store of review records. The records are appended to a file as json lines,
+// and all of them are kept in memory. It is only kept in memory if the path of file is empty.
+type reviewStore struct {
+	lock    sync.Mutex
+	file    *os.File
+	records map[string][]reviewRecord
+}
+
+func newReviewStore(path string) (*reviewStore, error) {
+	s := &reviewStore{records: map[string][]reviewRecord{}}
+	if path == "" {
+		return s, nil
+	}
+
+	if err := s.load(path); err != nil {
+		return nil, err
+	}
+
+	// the file only grows by appending, so it is rewritten without the dropped records
+	if err := s.compact(path); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *reviewStore) load(path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r reviewRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			logrus.WithError(err).Warning("skip a broken review record")
+			continue
+		}
+		key := prKey(r.Org, r.Repo, r.Number)
+		if r.Command == reviewActionDrop {
+			delete(s.records, key)
+		} else {
+			s.records[key] = append(s.records[key], r)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// compact writes the records in memory to a new file which replaces the one of path,
+// and keeps the new file open for the records to be appended.
+func (s *reviewStore) compact(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(s.records))
+	for k := range s.records {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	w := bufio.NewWriter(f)
+	for _, k := range keys {
+		for _, r := range s.records[k] {
+			data, err := json.Marshal(r)
+			if err == nil {
+				_, err = w.Write(append(data, '\n'))
+			}
+			if err != nil {
+				_ = f.Close()
+				return err
+			}
+		}
+	}
+	if err = w.Flush(); err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	if s.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// add saves the record, the time is set if it is zero.
+func (s *reviewStore) add(r reviewRecord) error {
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.write(r); err != nil {
+		return err
+	}
+
+	key := prKey(r.Org, r.Repo, r.Number)
+	s.records[key] = append(s.records[key], r)
+	return nil
+}
+
+// drop removes the records of the pull request, which is closed or merged.
+func (s *reviewStore) drop(org, repo, number string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := prKey(org, repo, number)
+	if _, ok := s.records[key]; !ok {
+		return nil
+	}
+
+	// the records in the file are dropped when it is loaded and compacted
+	err := s.write(reviewRecord{Org: org, Repo: repo, Number: number, Command: reviewActionDrop, Time: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	delete(s.records, key)
+	return nil
+}
+
+// write appends the record to the file, the lock must be held.
+func (s *reviewStore) write(r reviewRecord) error {
+	if s.file == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err = s.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	return s.file.Sync()
+}
+
+// list returns the records of the pull request in order.
+func (s *reviewStore) list(org, repo, number string) []reviewRecord {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	v := s.records[prKey(org, repo, number)]
+	r := make([]reviewRecord, len(v))
+	copy(r, v)
+
+	return r
+}
+
+// state replays the records of the pull request.
+func (s *reviewStore) state(org, repo, number string) reviewState {
+	st := reviewState{
+		lgtm:    map[string]reviewRecord{},
+		approve: map[string]reviewRecord{},
+		hold:    map[string]reviewRecord{},
+	}
+
+	for _, r := range s.list(org, repo, number) {
+		switch r.Command {
+		case reviewActionLgtm:
+			st.lgtm[r.Label] = r
+		case reviewActionLgtmCancel:
+			if r.Label == "" {
+				st.lgtm = map[string]reviewRecord{}
+			} else {
+				delete(st.lgtm, r.Label)
+			}
+		case reviewActionApprove:
+			st.approve[r.User] = r
+		case reviewActionApproveCancel:
+			st.approve = map[string]reviewRecord{}
+		case reviewActionClear:
+			// the reviews requesting changes are kept, the changes are still to be reviewed
+			st.lgtm = map[string]reviewRecord{}
+			st.approve = map[string]reviewRecord{}
+		case reviewActionHold:
+			st.hold[r.User] = r
+		case reviewActionUnhold:
+			delete(st.hold, r.User)
+		}
+	}
+
+	return st
+}
+
+// lastPatchID returns the latest patch id recorded for the pull request.
+func (s *reviewStore) lastPatchID(org, repo, number string) string {
+	v := s.list(org, repo, number)
+	for i := len(v) - 1; i >= 0; i-- {
+		if v[i].PatchID != "" {
+			return v[i].PatchID
+		}
+	}
+
+	return ""
+}
+
+func (s *reviewStore) close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
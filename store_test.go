@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReviewStoreDrop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reviews")
+
+	s, err := newReviewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, number := range []string{"1", "2"} {
+		r := reviewRecord{Org: testOrg, Repo: testRepo, Number: number, User: "alice", Command: reviewActionLgtm, Label: lgtmLabel}
+		if err := s.add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.drop(testOrg, testRepo, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if v := s.list(testOrg, testRepo, "1"); len(v) != 0 {
+		t.Fatalf("the records are not dropped: %v", v)
+	}
+	if err := s.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, err = newReviewStore(path); err != nil {
+		t.Fatal(err)
+	}
+	defer s.close()
+
+	if v := s.list(testOrg, testRepo, "1"); len(v) != 0 {
+		t.Errorf("the dropped records are loaded: %v", v)
+	}
+	if v := s.list(testOrg, testRepo, "2"); len(v) != 1 {
+		t.Errorf("unexpected records: %v", v)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(data, []byte("\n")); n != 1 {
+		t.Errorf("the file is not compacted, %d records are left", n)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/sirupsen/logrus"
+)
+
+const maxWebhookPayload = 5 << 20
+
+// webhookTranslator translates the webhooks of a platform into the generic events.
+type webhookTranslator interface {
+	// verify checks the signature of the payload by the secret.
+	verify(r *http.Request, payload, secret []byte) bool
+	// translate returns nil if the webhook is not handled by the robot.
+	// The action of a note, such as it is edited, is kept as the ActionDetail of the event.
+	translate(r *http.Request, payload []byte) (*client.GenericEvent, error)
+}
+
+// webhookAdapter receives the webhooks of a platform which the framework doesn't support,
+// and passes the translated events to the dispatcher of the framework as the orchestrated ones,
+// which are decoded into client.GenericEvent directly.
+type webhookAdapter struct {
+	translator webhookTranslator
+	secret     []byte
+	// target is the path where the dispatcher of the framework serves
+	target string
+	next   http.Handler
+	log    *logrus.Entry
+}
+
+func (a *webhookAdapter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookPayload))
+	if err != nil {
+		a.log.WithError(err).Warning("failed to read the webhook")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// the forwarded events are trusted by the framework, so the unverified ones are never passed,
+	// and the framework can't be reached from outside directly, see frameworkGuard
+	if len(a.secret) == 0 || !a.translator.verify(r, payload, a.secret) {
+		a.log.Warning("the signature of the webhook is invalid")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	evt, err := a.translator.translate(r, payload)
+	if err != nil {
+		a.log.WithError(err).Warning("failed to translate the webhook")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if evt == nil {
+		return
+	}
+
+	data, err := json.Marshal(evt)
+	if err != nil {
+		a.log.WithError(err).Warning("failed to encode the event")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, a.target, bytes.NewReader(data))
+	if err != nil {
+		a.log.WithError(err).Warning("failed to pass the event")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	req.Header.Set(client.HeaderRobotChain, client.HeaderRobotChainAuthed)
+	req.Header.Set("Content-Type", "application/json")
+
+	a.next.ServeHTTP(w, req)
+}
+
+// frameworkGuard rejects the requests from outside to the path of the dispatcher of the framework,
+// which trusts any event carrying the Robot-Chain header. The adapter passes the verified events
+// to the dispatcher in process, so they don't go through it.
+type frameworkGuard struct {
+	path string
+	next http.Handler
+	log  *logrus.Entry
+}
+
+func (g *frameworkGuard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if path.Clean(r.URL.Path) == g.path {
+		g.log.Warningf("reject the request to %s, the webhooks should be sent to %s/<platform>", g.path, g.path)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	g.next.ServeHTTP(w, r)
+}
+
+// registerWebhookAdapter serves the webhooks of the platform at '<handle path>/<platform>' if the framework
+// doesn't support them, and makes the server reject the requests to '<handle path>' from outside.
+func registerWebhookAdapter(server *http.Server, name, handlePath string, secret []byte, log *logrus.Entry) {
+	p, ok := platforms[name]
+	if !ok || p.translator == nil {
+		return
+	}
+
+	route := "/" + handlePath + "/" + name
+	http.Handle(route, &webhookAdapter{
+		translator: p.translator,
+		secret:     secret,
+		target:     "/" + handlePath,
+		next:       http.DefaultServeMux,
+		log:        log.WithField("platform", name),
+	})
+	server.Handler = &frameworkGuard{path: "/" + handlePath, next: http.DefaultServeMux, log: log}
+	log.Infof("the webhooks of %s are served at %s", name, route)
+}
+
+func newString(s string) *string {
+	return &s
+}
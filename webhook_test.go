@@ -0,0 +1,91 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/opensourceways/robot-framework-lib/client"
+	"github.com/sirupsen/logrus"
+)
+
+func TestFrameworkGuard(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	log := logrus.NewEntry(logger)
+	secret := []byte("secret")
+
+	dispatched := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhook", func(http.ResponseWriter, *http.Request) {
+		dispatched++
+	})
+	mux.Handle("/webhook/github", &webhookAdapter{
+		translator: githubWebhook{},
+		secret:     secret,
+		target:     "/webhook",
+		next:       mux,
+		log:        log,
+	})
+	server := &frameworkGuard{path: "/webhook", next: mux, log: log}
+
+	payload := `{"action":"opened","pull_request":{"number":1},"repository":{"name":"repo","owner":{"login":"org"}}}`
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(payload))
+
+	cases := []struct {
+		name       string
+		path       string
+		headers    map[string]string
+		code       int
+		dispatched int
+	}{
+		{
+			name:    "forged event to the framework",
+			path:    "/webhook",
+			headers: map[string]string{client.HeaderRobotChain: client.HeaderRobotChainAuthed},
+			code:    http.StatusForbidden,
+		},
+		{
+			name:    "forged event to the unclean path of the framework",
+			path:    "/webhook/",
+			headers: map[string]string{client.HeaderRobotChain: client.HeaderRobotChainAuthed},
+			code:    http.StatusForbidden,
+		},
+		{
+			name:    "unsigned webhook",
+			path:    "/webhook/github",
+			headers: map[string]string{githubHeaderEvent: githubEventPullRequest},
+			code:    http.StatusUnauthorized,
+		},
+		{
+			name: "signed webhook",
+			path: "/webhook/github",
+			headers: map[string]string{
+				githubHeaderEvent:     githubEventPullRequest,
+				githubHeaderSignature: githubSignaturePrefix + hex.EncodeToString(mac.Sum(nil)),
+			},
+			code:       http.StatusOK,
+			dispatched: 1,
+		},
+	}
+
+	for _, c := range cases {
+		dispatched = 0
+		r := httptest.NewRequest(http.MethodPost, c.path, strings.NewReader(payload))
+		for k, v := range c.headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, r)
+
+		if w.Code != c.code || dispatched != c.dispatched {
+			t.Errorf("%s: unexpected result, code: %d, dispatched: %d", c.name, w.Code, dispatched)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	wipLabel = "do-not-merge/work-in-progress"
+
+	commentAddWIP = `This pull request is a work in progress since %s, ***%s*** was added. :construction:
+It will not be merged until the title has no WIP prefix and it is ready for review.`
+	commentRemoveWIP = `This pull request is no longer a work in progress, ***%s*** was removed. :wave: `
+
+	msgWIPLabel = "PR is a work in progress, remove the WIP prefix of the title and mark it as ready to remove " +
+		"the label ***" + wipLabel + "***"
+	fixerWIP = "The author of the pull request by removing the WIP prefix of the title, marking it as ready " +
+		"for review or commenting `/wip cancel`."
+	descWIP = "A label which means the pull request is a work in progress or a draft."
+
+	reasonWIPTitle = "the title starts with ***%s***"
+	reasonWIPDraft = "it is a draft"
+
+	defaultWIPTitlePrefix = "[WIP]"
+)
+
+var defaultWIPTitlePrefixes = []string{defaultWIPTitlePrefix, "WIP:", "WIP "}
+
+func init() {
+	commands.register(&command{
+		name:    "wip",
+		args:    "[cancel]",
+		argsReg: regCancelArg,
+		role:    roleAuthor,
+		help: "Mark the pull request as a work in progress by adding the WIP prefix to the title, " +
+			"`/wip cancel` removes the prefix.",
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.handleWIPCommand(c)
+		},
+	})
+}
+
+// handleWIPCommand changes the title of the pull request, and the label follows the title.
+func (bot *robot) handleWIPCommand(c *commandContext) error {
+	logrus.Infof("handleWIPCommand, commenter: %s, org: %s, repo: %s, number: %s", c.commenter, c.org, c.repo, c.number)
+	info, ok := bot.cli.GetPullRequestInfo(c.org, c.repo, c.number)
+	if !ok {
+		return fmt.Errorf("failed to get pull request")
+	}
+
+	title := trimWIPPrefix(c.repoCnf, info.Title)
+	if !c.isCancel() {
+		title = defaultWIPTitlePrefix + " " + title
+	}
+
+	if title != info.Title {
+		if ok := bot.cli.UpdatePullRequestTitle(c.org, c.repo, c.number, title); !ok {
+			return fmt.Errorf("failed to update the title of pull request")
+		}
+		info.Title = title
+	}
+
+	return bot.syncWIPLabel(c.repoCnf, c.org, c.repo, c.number, info)
+}
+
+// handleWIP makes the work-in-progress label consistent with the title and the draft state.
+func (bot *robot) handleWIP(repoCnf *repoConfig, org, repo, number string) error {
+	info, ok := bot.cli.GetPullRequestInfo(org, repo, number)
+	if !ok {
+		return fmt.Errorf("failed to get pull request")
+	}
+
+	return bot.syncWIPLabel(repoCnf, org, repo, number, info)
+}
+
+func (bot *robot) syncWIPLabel(repoCnf *repoConfig, org, repo, number string, info pullRequestInfo) error {
+	reason := wipReason(bot.caps, repoCnf, info)
+	hasLabel := bot.getPRLabelSet(org, repo, number).Has(wipLabel)
+
+	switch {
+	case reason != "" && !hasLabel:
+		if ok := bot.cli.AddPRLabels(org, repo, number, []string{wipLabel}); !ok {
+			return fmt.Errorf("failed to add label on pull request")
+		}
+		bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentAddWIP, reason, wipLabel))
+	case reason == "" && hasLabel:
+		if ok := bot.cli.RemovePRLabels(org, repo, number, []string{wipLabel}); !ok {
+			return fmt.Errorf("failed to remove label on pull request")
+		}
+		bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(commentRemoveWIP, wipLabel))
+	}
+
+	return nil
+}
+
+// checkWIP checks the pull request itself besides the label, which may be removed by hand while the pull request
+// is still a work in progress. The label is added back if so.
+func (bot *robot) checkWIP(repoCnf *repoConfig, org, repo, number string, info pullRequestInfo) error {
+	if wipReason(bot.caps, repoCnf, info) == "" {
+		return nil
+	}
+
+	if err := bot.syncWIPLabel(repoCnf, org, repo, number, info); err != nil {
+		return err
+	}
+
+	return errors.New(msgWIPLabel)
+}
+
+// wipReason returns why the pull request is a work in progress, an empty string means it isn't.
+// The draft state is only checked on the platforms which support the drafts.
+func wipReason(caps *capabilities, repoCnf *repoConfig, info pullRequestInfo) string {
+	if p := matchWIPPrefix(repoCnf, info.Title); p != "" {
+		return fmt.Sprintf(reasonWIPTitle, p)
+	}
+
+	if caps.draft && info.Draft {
+		return reasonWIPDraft
+	}
+
+	return ""
+}
+
+// matchWIPPrefix returns the prefix which the title starts with. The title is compared without changing it,
+// so the prefix can be trimmed by its length.
+func matchWIPPrefix(repoCnf *repoConfig, title string) string {
+	t := strings.TrimSpace(title)
+	for _, p := range repoCnf.wipTitlePrefixes() {
+		if len(t) >= len(p) && strings.EqualFold(t[:len(p)], p) {
+			return p
+		}
+	}
+
+	return ""
+}
+
+func trimWIPPrefix(repoCnf *repoConfig, title string) string {
+	for {
+		p := matchWIPPrefix(repoCnf, title)
+		if p == "" {
+			return title
+		}
+		title = strings.TrimSpace(strings.TrimSpace(title)[len(p):])
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestTrimWIPPrefix(t *testing.T) {
+	cases := []struct {
+		title string
+		want  string
+	}{
+		{title: "[wip] fix the crash", want: "fix the crash"},
+		{title: "  WIP: wip  fix the crash", want: "fix the crash"},
+		{title: "Wipe the cache", want: "Wipe the cache"},
+		// the upper case of 'ı' is shorter than it
+		{title: "wıp: fix the crash", want: "wıp: fix the crash"},
+	}
+
+	repoCnf := &repoConfig{}
+	for _, c := range cases {
+		if got := trimWIPPrefix(repoCnf, c.title); got != c.want {
+			t.Errorf("trimWIPPrefix(%q) = %q, want %q", c.title, got, c.want)
+		}
+	}
+}